@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// Returns an error if the hist options can't be used with a checkpoint, since
+// a later run only tallies the commits made after the saved head.
+func checkCheckpointOpts(opts histOpts) error {
+	if opts.checkpointPath == "" {
+		return nil
+	}
+
+	// These all need to see the whole history at once. (A new tag splits the
+	// latest release range, which is already saved.)
+	if opts.byClock || opts.surviving || opts.firstCommitOnly ||
+		opts.halfLifeDays > 0 || opts.resolution == tally.ReleaseResolution {
+		return errors.New(
+			"-checkpoint cannot be used with -clock, -surviving, " +
+				"-first-commit, -half-life, or -releases",
+		)
+	}
+
+	// Merges are credited with what they landed relative to history that was
+	// already tallied, so they would be counted wrong
+	if opts.mergeDiffs || opts.firstParent {
+		return errors.New(
+			"-checkpoint cannot be used with -merge-diffs or -first-parent",
+		)
+	}
+
+	// Commits left out by these would be skipped for good once the saved head
+	// moves past them
+	if opts.asOf != "" || opts.until != "" {
+		return errors.New("-checkpoint cannot be used with -as-of or -until")
+	}
+
+	return nil
+}
+
+// Reads the checkpoint saved at the given path by a previous run. Returns an
+// empty checkpoint if there is no such file yet.
+func readCheckpointFile(path string) (_ tally.Checkpoint, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading checkpoint file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tally.Checkpoint{}, nil
+	} else if err != nil {
+		return tally.Checkpoint{}, err
+	}
+	defer f.Close()
+
+	checkpoint, err := tally.ReadCheckpoint(bufio.NewReader(f))
+	if err != nil {
+		return tally.Checkpoint{}, err
+	}
+
+	logger().Debug(
+		"read checkpoint",
+		"head",
+		checkpoint.Head,
+		"buckets",
+		len(checkpoint.Series),
+	)
+	return checkpoint, nil
+}
+
+// Saves the checkpoint to the given path.
+//
+// We write to a temporary file first and then move it into place, so that an
+// interrupted run never leaves a truncated checkpoint behind.
+func writeCheckpointFile(path string, checkpoint tally.Checkpoint) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error writing checkpoint file: %w", err)
+		}
+	}()
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // No-op once renamed
+
+	w := bufio.NewWriter(f)
+	err = checkpoint.Write(w)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestCheckCheckpointOpts(t *testing.T) {
+	tests := []struct {
+		name string
+		opts histOpts
+	}{
+		{name: "clock", opts: histOpts{byClock: true}},
+		{name: "surviving", opts: histOpts{surviving: true}},
+		{name: "first_commit", opts: histOpts{firstCommitOnly: true}},
+		{name: "half_life", opts: histOpts{halfLifeDays: 180}},
+		{
+			name: "releases",
+			opts: histOpts{resolution: tally.ReleaseResolution},
+		},
+		{name: "merge_diffs", opts: histOpts{mergeDiffs: true}},
+		{name: "first_parent", opts: histOpts{firstParent: true}},
+		{name: "as_of", opts: histOpts{asOf: "2024-01-01"}},
+		{name: "until", opts: histOpts{until: "2024-01-01"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.checkpointPath = "checkpoint.gob"
+			if err := checkCheckpointOpts(opts); err == nil {
+				t.Errorf("expected error but got none")
+			}
+
+			opts.checkpointPath = ""
+			if err := checkCheckpointOpts(opts); err != nil {
+				t.Errorf("expected no error without -checkpoint but got %v", err)
+			}
+		})
+	}
+
+	opts := histOpts{checkpointPath: "checkpoint.gob", since: "2024-01-01"}
+	if err := checkCheckpointOpts(opts); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/mattn/go-runewidth v0.0.16
 	golang.org/x/term v0.28.0
+	golang.org/x/text v0.21.0
 )
 
 require (
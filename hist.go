@@ -2,32 +2,86 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
+	"os/signal"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sinclairtarget/git-who/internal/concurrent"
 	"github.com/sinclairtarget/git-who/internal/format"
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/pretty"
 	"github.com/sinclairtarget/git-who/internal/tally"
+	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
 )
 
 const barWidth = 36
 
+type histOpts struct {
+	mode            tally.TallyMode
+	churnWeight     *float64
+	resolution      tally.ResolutionMode
+	weekendToFriday bool
+	fiscalYearStart time.Month
+	interval        int
+	anchor          string
+	labelFormat     string
+	trimEmpty       bool
+	byClock         bool
+	clock           tally.ClockMode
+	loc             *time.Location
+	dateSource      tally.DateSource
+	asOf            string
+	mailmap         git.Mailmap
+	aliases         git.Aliases
+	teams           map[string]string
+	unknownTeam     string
+	extensions      []string
+	globs           []string
+	messageFilter   *regexp.Regexp
+	useJson         bool
+	useCsv          bool
+	useMarkdown     bool
+	showDebug       bool
+	showBreakdown   bool
+	byDir           bool
+	byDomain        bool
+	bySignature     bool
+	verifiedOnly    bool
+	top             int
+	minCommits      int
+	minShare        float64
+	showEmail       bool
+	anonymize       bool
+	countMerges     bool
+	mergeDiffs      bool
+	firstParent     bool
+	firstCommitOnly bool
+	surviving       bool
+	noBots          bool
+	noGenerated     bool
+	maxDiffLines    int
+	coAuthors       bool
+	halfLifeDays    int
+	checkpointPath  string
+	since           string
+	until           string
+	authors         []string
+	nauthors        []string
+}
+
 func hist(
 	revs []string,
 	paths []string,
-	mode tally.TallyMode,
-	showEmail bool,
-	countMerges bool,
-	since string,
-	until string,
-	authors []string,
-	nauthors []string,
+	opts histOpts,
 ) (err error) {
 	defer func() {
 		if err != nil {
@@ -41,62 +95,193 @@ func hist(
 		revs,
 		"paths",
 		paths,
-		"mode",
-		mode,
-		"showEmail",
-		showEmail,
-		"countMerges",
-		countMerges,
-		"since",
-		since,
-		"until",
-		until,
-		"authors",
-		authors,
-		"nauthors",
-		nauthors,
+		"opts",
+		opts,
 	)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Stop cleanly on Ctrl-C. A second Ctrl-C kills us as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
-	if showEmail {
+	tallyOpts := tally.TallyOpts{
+		Mode:            opts.mode,
+		CountMerges:     opts.countMerges,
+		MergeDiffs:      opts.mergeDiffs || opts.firstParent,
+		FirstCommitOnly: opts.firstCommitOnly,
+		Resolution:      opts.resolution,
+		WeekendToFriday: opts.weekendToFriday,
+		FiscalYearStart: opts.fiscalYearStart,
+		Interval:        opts.interval,
+		TrimEmpty:       opts.trimEmpty,
+		Location:        opts.loc,
+		DateSource:      opts.dateSource,
+		Mailmap:         opts.mailmap,
+		Aliases:         opts.aliases,
+		Teams:           opts.teams,
+		UnknownTeam:     opts.unknownTeam,
+		Extensions:      opts.extensions,
+		PathFilter:      opts.globs,
+		MessageFilter:   opts.messageFilter,
+		CoAuthors:       opts.coAuthors,
+		ChurnWeight:     opts.churnWeight,
+		MaxDiffLines:    opts.maxDiffLines,
+	}
+	tallyOpts.ExcludePaths, err = readIgnoreFile()
+	if err != nil {
+		return err
+	}
+	if opts.labelFormat != "" {
+		tallyOpts.LabelFormats = map[tally.ResolutionMode]string{}
+		for _, m := range []tally.ResolutionMode{
+			tally.DailyResolution,
+			tally.BusinessDayResolution,
+			tally.WeeklyResolution,
+			tally.MonthlyResolution,
+			tally.QuarterlyResolution,
+			tally.YearlyResolution,
+			tally.IntervalResolution,
+		} {
+			tallyOpts.LabelFormats[m] = opts.labelFormat
+		}
+	}
+	if opts.noBots {
+		tallyOpts.ExcludeAuthors = tally.DefaultBotPatterns
+	}
+	if opts.noGenerated {
+		tallyOpts.PathWeight = tally.GeneratedFileWeight
+	}
+	if opts.byDir {
+		tallyOpts.DiffKey = tally.TopLevelDirKey
+	}
+	if opts.byDomain {
+		tallyOpts.GroupBy = tally.EmailDomainGroup
+	}
+	if opts.bySignature {
+		tallyOpts.GroupBy = tally.SignatureGroup
+	}
+	tallyOpts.VerifiedOnly = opts.verifiedOnly
+	if opts.halfLifeDays > 0 {
+		tallyOpts.Decay = tally.HalfLifeDecay(
+			time.Duration(opts.halfLifeDays) * 24 * time.Hour,
+		)
+	}
+
+	// Git already filters by these, but we also want the timeline to span
+	// the whole window
+	if opts.since != "" {
+		tallyOpts.Since, err = git.ParseDate(opts.since)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.until != "" {
+		tallyOpts.Until, err = git.ParseDate(opts.until)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.asOf != "" {
+		tallyOpts.AsOf, err = git.ParseDate(opts.asOf)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.anchor != "" {
+		tallyOpts.Anchor, err = git.ParseDate(opts.anchor)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.resolution == tally.ReleaseResolution {
+		tags, err := git.Tags()
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return errors.New("no tags to bucket commits by")
+		}
+
+		for _, tag := range tags {
+			tallyOpts.Releases = append(
+				tallyOpts.Releases,
+				tally.Release{Name: tag.Name, Time: tag.Time},
+			)
+		}
+	}
+	if opts.showEmail {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
 	} else {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
 	}
 
-	populateDiffs := tallyOpts.IsDiffMode()
+	err = tallyOpts.Validate()
+	if err != nil {
+		return err
+	}
+
+	populateDiffs := tallyOpts.NeedsDiffs()
 	filters := git.LogFilters{
-		Since:    since,
-		Until:    until,
-		Authors:  authors,
-		Nauthors: nauthors,
+		Since:    opts.since,
+		Until:    opts.until,
+		Authors:  opts.authors,
+		Nauthors: opts.nauthors,
+
+		FirstParent: opts.firstParent,
 	}
 
 	var end time.Time // Default is zero time, meaning use last commit
-	if len(revs) == 1 && revs[0] == "HEAD" && len(until) == 0 {
+	if len(revs) == 1 && revs[0] == "HEAD" && len(opts.until) == 0 {
 		// If no revs or --until given, end timeline at current time
 		end = time.Now()
 	}
 
-	var buckets []tally.TimeBucket
-	if populateDiffs && runtime.GOMAXPROCS(0) > 1 {
-		buckets, err = concurrent.TallyCommitsTimeline(
-			ctx,
-			revs,
-			paths,
-			filters,
-			tallyOpts,
-			end,
-			getCache(),
-			pretty.AllowDynamic(os.Stdout),
-		)
+	// Only tally commits we haven't already saved in the checkpoint
+	var checkpoint tally.Checkpoint
+	var head string
+	if opts.checkpointPath != "" {
+		if len(revs) != 1 {
+			return errors.New("-checkpoint needs a single revision")
+		}
+
+		head, err = git.ResolveRev(revs[0])
 		if err != nil {
 			return err
 		}
-	} else {
+
+		checkpoint, err = readCheckpointFile(opts.checkpointPath)
+		if err != nil {
+			return err
+		}
+
+		if checkpoint.Head != "" {
+			revs = append(revs, "^"+checkpoint.Head)
+		}
+	}
+
+	// Signatures aren't part of the cached git log output, so they're looked
+	// up on their own and the commits have to come through here
+	var signatures map[string]git.SignatureStatus
+	needSignatures := opts.bySignature || opts.verifiedOnly
+	if needSignatures {
+		signatures, err = git.Signatures(ctx, revs, paths, filters)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Crediting first commits and finding merged commits need to see every
+	// commit at once, so we can't tally chunks of commits separately
+	useConcurrent := populateDiffs && !opts.firstCommitOnly &&
+		!tallyOpts.MergeDiffs &&
+		!needSignatures
+
+	var buckets []tally.TimeBucket
+	var resolution tally.ResolutionMode
+	if opts.byClock {
 		commits, closer, err := git.CommitsWithOpts(
 			ctx,
 			revs,
@@ -108,16 +293,90 @@ func hist(
 			return err
 		}
 
-		buckets, err = tally.TallyCommitsTimeline(
-			commits,
+		commits = git.WithSignatures(commits, signatures)
+		buckets, err = tally.TallyCommitsByClock(commits, tallyOpts, opts.clock)
+		if err != nil {
+			return err
+		}
+
+		err = closer()
+		if err != nil {
+			return err
+		}
+	} else if opts.surviving {
+		if len(revs) != 1 {
+			return errors.New("-surviving needs a single revision")
+		}
+
+		commits, err := git.SurvivingCommits(ctx, revs[0], paths)
+		if err != nil {
+			return err
+		}
+
+		buckets, resolution, err = tally.TallyCommitsTimeline(
+			git.WithSignatures(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				signatures,
+			),
 			tallyOpts,
 			end,
 		)
 		if err != nil {
 			return err
 		}
+	} else {
+		var daily tally.TimeSeries
+		if useConcurrent && runtime.GOMAXPROCS(0) > 1 {
+			daily, err = concurrent.TallyCommitsByDate(
+				ctx,
+				revs,
+				paths,
+				filters,
+				tallyOpts,
+				getCache(),
+				pretty.AllowDynamic(os.Stdout),
+			)
+			if err != nil {
+				return err
+			}
+		} else {
+			commits, closer, err := git.CommitsWithOpts(
+				ctx,
+				revs,
+				paths,
+				filters,
+				populateDiffs,
+			)
+			if err != nil {
+				return err
+			}
 
-		err = closer()
+			commits = git.WithSignatures(commits, signatures)
+			daily, err = tally.TallyCommitsByDateContext(ctx, commits, tallyOpts)
+			if err != nil {
+				return err
+			}
+
+			err = closer()
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.checkpointPath != "" {
+			daily, err = checkpoint.Series.Combine(daily)
+			if err != nil {
+				return err
+			}
+
+			checkpoint = tally.Checkpoint{Head: head, Series: daily}
+			err = writeCheckpointFile(opts.checkpointPath, checkpoint)
+			if err != nil {
+				return err
+			}
+		}
+
+		buckets, resolution, err = tally.ToTimeline(daily, tallyOpts, end)
 		if err != nil {
 			return err
 		}
@@ -125,38 +384,124 @@ func hist(
 
 	// -- Pick winner in each bucket --
 	for i, bucket := range buckets {
-		buckets[i] = bucket.Rank(mode)
+		buckets[i] = bucket.Rank(opts.mode)
+	}
+
+	if opts.minCommits > 0 {
+		buckets = tally.TimeSeries(buckets).DropMinorAuthors(
+			opts.minCommits,
+			true,
+			opts.mode,
+		)
+	}
+
+	if opts.top > 0 {
+		for i, bucket := range buckets {
+			buckets[i] = bucket.Prune(opts.top, opts.mode)
+		}
+	}
+
+	if opts.minShare > 0 {
+		for i, bucket := range buckets {
+			buckets[i] = bucket.FoldBelow(opts.minShare/100, opts.mode)
+		}
+	}
+
+	if opts.anonymize {
+		buckets = tally.TimeSeries(buckets).Anonymize()
+	}
+
+	if opts.useJson {
+		return writeHistJson(buckets, opts.showBreakdown)
+	}
+
+	if opts.useCsv {
+		return tally.TimeSeries(buckets).WriteCSV(os.Stdout)
+	}
+
+	if opts.useMarkdown {
+		return tally.TimeSeries(buckets).WriteMarkdown(os.Stdout, opts.mode)
+	}
+
+	if opts.showDebug {
+		for _, bucket := range buckets {
+			fmt.Print(bucket.Debug())
+		}
+		return nil
 	}
 
 	// -- Draw bar plot --
-	maxVal := barWidth
+	maxVal := int64(barWidth)
 	for _, bucket := range buckets {
-		if bucket.TotalValue(mode) > maxVal {
-			maxVal = bucket.TotalValue(mode)
+		if bucket.TotalValue(opts.mode) > maxVal {
+			maxVal = bucket.TotalValue(opts.mode)
 		}
 	}
 
-	drawPlot(buckets, maxVal, mode, showEmail)
+	if len(buckets) > 0 && !opts.byClock {
+		fmt.Printf(
+			"Resolution: %s (%s – %s)\n",
+			resolution,
+			buckets[0].Name,
+			buckets[len(buckets)-1].Name,
+		)
+	}
+
+	drawPlot(buckets, maxVal, opts.mode, opts.showEmail)
+	return nil
+}
+
+func writeHistJson(buckets []tally.TimeBucket, showBreakdown bool) error {
+	var v any = tally.TimeSeries(buckets)
+	if showBreakdown {
+		v = tally.DetailedTimeSeries(buckets)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error writing JSON to stdout: %w", err)
+	}
+
 	return nil
 }
 
 func drawPlot(
 	buckets []tally.TimeBucket,
-	maxVal int,
+	maxVal int64,
 	mode tally.TallyMode,
 	showEmail bool,
 ) {
+	// Bucket names aren't always the same length, e.g. for age bands
+	nameWidth := 0
+	for _, bucket := range buckets {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(bucket.Name))
+	}
+
 	var lastAuthor string
+	hasPartial := false
 	for _, bucket := range buckets {
+		name := bucket.Name + strings.Repeat(
+			" ",
+			nameWidth-utf8.RuneCountInString(bucket.Name),
+		)
+
+		// A broken axis marks periods the timeline only covers some of
+		axis := "┤"
+		if bucket.Partial {
+			axis = "┊"
+			hasPartial = true
+		}
+
+		// Values can be negative in net lines mode; we just draw no bar
 		value := bucket.Value(mode)
-		clampedValue := int(math.Ceil(
-			(float64(value) / float64(maxVal)) * float64(barWidth),
-		))
+		clampedValue := max(0, int(math.Ceil(
+			(float64(value)/float64(maxVal))*float64(barWidth),
+		)))
 
 		total := bucket.TotalValue(mode)
-		clampedTotal := int(math.Ceil(
-			(float64(total) / float64(maxVal)) * float64(barWidth),
-		))
+		clampedTotal := max(clampedValue, int(math.Ceil(
+			(float64(total)/float64(maxVal))*float64(barWidth),
+		)))
 
 		valueBar := strings.Repeat("#", clampedValue)
 		totalBar := strings.Repeat("-", clampedTotal-clampedValue)
@@ -169,8 +514,9 @@ func drawPlot(
 				bucket.Tally.AuthorName == lastAuthor,
 			)
 			fmt.Printf(
-				"%s ┤ %s%s%-*s%s  %s\n",
-				bucket.Name,
+				"%s %s %s%s%-*s%s  %s\n",
+				name,
+				axis,
 				valueBar,
 				pretty.Dim,
 				barWidth-clampedValue,
@@ -181,9 +527,18 @@ func drawPlot(
 
 			lastAuthor = bucket.Tally.AuthorName
 		} else {
-			fmt.Printf("%s ┤ \n", bucket.Name)
+			fmt.Printf("%s %s \n", name, axis)
 		}
 	}
+
+	if hasPartial {
+		fmt.Printf(
+			"%s%s ┊ partial period%s\n",
+			pretty.Dim,
+			strings.Repeat(" ", nameWidth),
+			pretty.Reset,
+		)
+	}
 }
 
 func fmtHistTally(
@@ -198,7 +553,7 @@ func fmtHistTally(
 		metric = fmt.Sprintf("(%s)", format.Number(t.Commits))
 	case tally.FilesMode:
 		metric = fmt.Sprintf("(%s)", format.Number(t.FileCount))
-	case tally.LinesMode:
+	case tally.LinesMode, tally.NetLinesMode, tally.ChurnMode:
 		metric = fmt.Sprintf(
 			"(%s%s%s / %s%s%s)",
 			pretty.Green,
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// Name of the file at the repo root listing paths to leave out of tallies.
+const ignoreFilename = ".git-who-ignore"
+
+// Reads the patterns from the repo's .git-who-ignore file, which uses
+// gitignore syntax. Returns no patterns if there is no such file.
+func readIgnoreFile() (_ []string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading %s: %w", ignoreFilename, err)
+		}
+	}()
+
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(gitRootPath, ignoreFilename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while scanning: %w", err)
+	}
+
+	logger().Debug("read ignore file", "patterns", patterns)
+	return patterns, nil
+}
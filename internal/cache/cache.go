@@ -197,12 +197,17 @@ func CacheStorageDir(name string) (_ string, err error) {
 	return absP, nil
 }
 
+// Bump this whenever the fields stored for each commit change, so that caches
+// written by older versions of git-who get thrown away.
+const commitFormatVersion = 7
+
 // Returns a hash of state in the repo that, if changed, should invalidate our
 // cache.
 func RepoStateHash(gitRootPath string) (string, error) {
 	mailmapPath := filepath.Join(gitRootPath, ".mailmap")
 
 	h := fnv.New32()
+	fmt.Fprintf(h, "v%d", commitFormatVersion)
 
 	f, err := os.Open(mailmapPath)
 	if errors.Is(err, fs.ErrNotExist) {
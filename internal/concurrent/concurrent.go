@@ -25,7 +25,6 @@ import (
 	"fmt"
 	"iter"
 	"runtime"
-	"time"
 
 	"github.com/sinclairtarget/git-who/internal/cache"
 	"github.com/sinclairtarget/git-who/internal/format"
@@ -48,16 +47,15 @@ type tallyFunc[T any] func(
 	opts tally.TallyOpts,
 ) (T, error)
 
-type combinable[T any] interface {
-	Combine(other T) T
-}
+type combineFunc[T any] func(a T, b T) (T, error)
 
 // tally job we can do concurrently
-type whoperation[T combinable[T]] struct {
+type whoperation[T any] struct {
 	revspec []string
 	paths   []string
 	filters git.LogFilters
 	tally   tallyFunc[T]
+	combine combineFunc[T]
 	opts    tally.TallyOpts
 }
 
@@ -86,7 +84,7 @@ func setDiff(a []string, b []string) []string {
 	return ret
 }
 
-func accumulateCached[T combinable[T]](
+func accumulateCached[T any](
 	whop whoperation[T],
 	c cache.Cache,
 	revs []string,
@@ -119,7 +117,7 @@ func handleCacheFailure(c cache.Cache, err error) error {
 	return c.Clear()
 }
 
-func tallyFanOutFanIn[T combinable[T]](
+func tallyFanOutFanIn[T any](
 	ctx context.Context,
 	whop whoperation[T],
 	cache cache.Cache,
@@ -251,7 +249,10 @@ loop:
 				break loop
 			}
 
-			accumulator = accumulator.Combine(result)
+			accumulator, err = whop.combine(accumulator, result)
+			if err != nil {
+				return accumulator, err
+			}
 			chunksComplete += 1
 
 			if showProgress {
@@ -291,6 +292,15 @@ loop:
 	return accumulator, nil
 }
 
+// By-path tallies always combine, but the fan-in also has to handle time
+// series, which can fail to.
+func combineByPath(
+	a tally.TalliesByPath,
+	b tally.TalliesByPath,
+) (tally.TalliesByPath, error) {
+	return a.Combine(b), nil
+}
+
 func TallyCommits(
 	ctx context.Context,
 	revspec []string,
@@ -305,6 +315,7 @@ func TallyCommits(
 		paths:   paths,
 		filters: filters,
 		tally:   tally.TallyCommitsByPath,
+		combine: combineByPath,
 		opts:    opts,
 	}
 
@@ -337,6 +348,7 @@ func TallyCommitsTree(
 		paths:   paths,
 		filters: filters,
 		tally:   tally.TallyCommitsByPath,
+		combine: combineByPath,
 		opts:    opts,
 	}
 
@@ -357,21 +369,21 @@ func TallyCommitsTree(
 	)
 }
 
-func TallyCommitsTimeline(
+// Tallies commits into daily buckets, as tally.TallyCommitsByDate() does.
+func TallyCommitsByDate(
 	ctx context.Context,
 	revspec []string,
 	paths []string,
 	filters git.LogFilters,
 	opts tally.TallyOpts,
-	end time.Time,
 	cache cache.Cache,
 	allowProgressBar bool,
-) ([]tally.TimeBucket, error) {
+) (tally.TimeSeries, error) {
 	f := func(
 		commits iter.Seq2[git.Commit, error],
 		opts tally.TallyOpts,
 	) (tally.TimeSeries, error) {
-		return tally.TallyCommitsByDate(commits, opts)
+		return tally.TallyCommitsByDateContext(ctx, commits, opts)
 	}
 
 	whop := whoperation[tally.TimeSeries]{
@@ -379,23 +391,14 @@ func TallyCommitsTimeline(
 		paths:   paths,
 		filters: filters,
 		tally:   f,
+		combine: tally.TimeSeries.Combine,
 		opts:    opts,
 	}
 
-	buckets, err := tallyFanOutFanIn[tally.TimeSeries](
+	return tallyFanOutFanIn[tally.TimeSeries](
 		ctx,
 		whop,
 		cache,
 		allowProgressBar,
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	if end.IsZero() {
-		end = buckets[len(buckets)-1].Time
-	}
-	resolution := tally.CalcResolution(buckets[0].Time, end)
-	rebuckets := tally.Rebucket(buckets, resolution, end)
-	return rebuckets, nil
 }
@@ -31,7 +31,7 @@ func runWriter(ctx context.Context, revs []string, q chan<- []string) {
 }
 
 // Spawner. Creates new workers while we have free CPUs and work to do.
-func runSpawner[T combinable[T]](
+func runSpawner[T any](
 	ctx context.Context,
 	whop whoperation[T],
 	q <-chan []string,
@@ -147,7 +147,7 @@ loop:
 }
 
 // A tally worker that runs git log for each chunk of work.
-func runWorker[T combinable[T]](
+func runWorker[T any](
 	ctx context.Context,
 	id int,
 	whop whoperation[T],
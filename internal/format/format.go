@@ -82,7 +82,7 @@ func RelativeTime(now time.Time, t time.Time) string {
 }
 
 // Adds thousands comma and abbreviates numbers > 1m
-func Number(num int) string {
+func Number[N ~int | ~int64](num N) string {
 	if num < 0 {
 		panic("cannot format negative number")
 	}
@@ -0,0 +1,128 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Maps variants of author identities to canonical identities, e.g. for people
+// who changed their name or moved between companies.
+//
+// Aliases are read from a JSON list of entries like:
+//
+//	[
+//		{
+//			"canonical": "Jane Doe <jane@corp.com>",
+//			"aliases": ["jane@old.com", "Jane Smith"]
+//		}
+//	]
+//
+// Each alias is either an email, matched case-insensitively, or a name,
+// matched exactly. An email alias takes precedence over a name alias.
+type Aliases struct {
+	byEmail map[string]Author // Lowercased alias email -> canonical identity
+	byName  map[string]Author
+}
+
+type aliasEntry struct {
+	Canonical string   `json:"canonical"`
+	Aliases   []string `json:"aliases"`
+}
+
+// Reads a JSON aliases file from the given path.
+func ReadAliases(path string) (_ Aliases, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading aliases file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Aliases{}, err
+	}
+	defer f.Close()
+
+	return ParseAliases(f)
+}
+
+func ParseAliases(r io.Reader) (Aliases, error) {
+	a := Aliases{byEmail: map[string]Author{}, byName: map[string]Author{}}
+
+	var entries []aliasEntry
+	err := json.NewDecoder(r).Decode(&entries)
+	if err != nil {
+		return a, fmt.Errorf("error decoding aliases: %w", err)
+	}
+
+	for _, entry := range entries {
+		canonical, err := parseIdentity(entry.Canonical)
+		if err != nil {
+			return a, err
+		}
+
+		for _, alias := range entry.Aliases {
+			alias = strings.TrimSpace(alias)
+			aliases := a.byName
+			if strings.Contains(alias, "@") {
+				alias = strings.ToLower(alias)
+				aliases = a.byEmail
+			}
+
+			if existing, ok := aliases[alias]; ok && existing != canonical {
+				return a, fmt.Errorf(
+					"alias \"%s\" is given for both \"%s\" and \"%s\"",
+					alias,
+					existing.Name,
+					canonical.Name,
+				)
+			}
+			aliases[alias] = canonical
+		}
+	}
+
+	return a, nil
+}
+
+// Parses an identity of the form "Name <email>".
+func parseIdentity(s string) (Author, error) {
+	start := strings.IndexByte(s, '<')
+	end := strings.IndexByte(s, '>')
+	name := strings.TrimSpace(s[:max(start, 0)])
+	if start < 0 || end < start || name == "" {
+		return Author{}, fmt.Errorf(
+			"canonical identity should look like \"Name <email>\": \"%s\"",
+			s,
+		)
+	}
+
+	return Author{Name: name, Email: s[start+1 : end]}, nil
+}
+
+// Returns the canonical name and email for the given identity.
+func (a Aliases) Resolve(name string, email string) (string, string) {
+	if canonical, ok := a.byEmail[strings.ToLower(email)]; ok {
+		return canonical.Name, canonical.Email
+	}
+	if canonical, ok := a.byName[name]; ok {
+		return canonical.Name, canonical.Email
+	}
+
+	return name, email
+}
+
+// Returns the commit with its author identity canonicalized.
+func (a Aliases) Apply(commit Commit) Commit {
+	if len(a.byEmail) == 0 && len(a.byName) == 0 {
+		return commit
+	}
+
+	commit.AuthorName, commit.AuthorEmail = a.Resolve(
+		commit.AuthorName,
+		commit.AuthorEmail,
+	)
+	return commit
+}
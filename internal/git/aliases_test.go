@@ -0,0 +1,79 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+const testAliases = `[
+	{
+		"canonical": "Jane Doe <jane@corp.com>",
+		"aliases": ["Jane@Old.com", "Jane Smith"]
+	},
+	{
+		"canonical": "Jim Jones <jim@corp.com>",
+		"aliases": ["jimbo@startup.io"]
+	}
+]`
+
+func TestAliasesResolve(t *testing.T) {
+	aliases, err := git.ParseAliases(strings.NewReader(testAliases))
+	if err != nil {
+		t.Fatalf("ParseAliases() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		expName  string
+		expEmail string
+	}{
+		{"jane", "jane@old.com", "Jane Doe", "jane@corp.com"},
+		{"Jane Smith", "jsmith@gmail.com", "Jane Doe", "jane@corp.com"},
+		{"Jane Smith", "jimbo@startup.io", "Jim Jones", "jim@corp.com"},
+		{"zed", "zed@corp.com", "zed", "zed@corp.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.email, func(t *testing.T) {
+			name, email := aliases.Resolve(test.name, test.email)
+			if name != test.expName || email != test.expEmail {
+				t.Errorf(
+					"expected %s <%s> but got %s <%s>",
+					test.expName,
+					test.expEmail,
+					name,
+					email,
+				)
+			}
+		})
+	}
+}
+
+func TestParseAliasesMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases string
+	}{
+		{"not_a_list", `{"canonical": "Jane Doe <jane@corp.com>"}`},
+		{"no_email", `[{"canonical": "Jane Doe", "aliases": ["jane"]}]`},
+		{
+			"duplicate_alias",
+			`[
+				{"canonical": "Jane Doe <jane@corp.com>", "aliases": ["j@x.com"]},
+				{"canonical": "Jim Jones <jim@corp.com>", "aliases": ["J@x.com"]}
+			]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := git.ParseAliases(strings.NewReader(test.aliases))
+			if err == nil {
+				t.Errorf("expected ParseAliases() to return error")
+			}
+		})
+	}
+}
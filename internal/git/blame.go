@@ -0,0 +1,189 @@
+package git
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Each line in a file as given by git blame --line-porcelain.
+type blameLine struct {
+	hash   string
+	commit Commit // Without file diffs
+}
+
+// Parses the output of git blame --line-porcelain.
+func parseBlame(lines iter.Seq2[string, error]) iter.Seq2[blameLine, error] {
+	return func(yield func(blameLine, error) bool) {
+		var line blameLine
+		var authorTime, committerTime string
+		atHeader := true
+
+		for text, err := range lines {
+			if err != nil {
+				yield(line, fmt.Errorf("error reading blame output: %w", err))
+				return
+			}
+
+			if atHeader {
+				hash, _, _ := strings.Cut(text, " ")
+				line = blameLine{hash: hash}
+				line.commit.Hash = hash
+				// Blame only gives full hashes, but tallies count commits
+				// by short hash
+				line.commit.ShortHash = hash[:min(len(hash), 12)]
+				atHeader = false
+				continue
+			}
+
+			if strings.HasPrefix(text, "\t") {
+				// Contents of the line, which ends the entry
+				var err error
+				line.commit.Date, err = parseRawDate(authorTime)
+				if err == nil {
+					line.commit.CommitterDate, err = parseRawDate(committerTime)
+				}
+				if err != nil {
+					yield(line, fmt.Errorf(
+						"error parsing blame date for commit %s: %w",
+						line.hash,
+						err,
+					))
+					return
+				}
+
+				if !yield(line, nil) {
+					return
+				}
+
+				atHeader = true
+				continue
+			}
+
+			key, value, _ := strings.Cut(text, " ")
+			switch key {
+			case "author":
+				line.commit.AuthorName = value
+			case "author-mail":
+				line.commit.AuthorEmail = strings.Trim(value, "<>")
+			case "author-time":
+				authorTime = value
+			case "author-tz":
+				authorTime += " " + value
+			case "committer-time":
+				committerTime = value
+			case "committer-tz":
+				committerTime += " " + value
+			case "summary":
+				line.commit.Subject = value
+			}
+		}
+	}
+}
+
+// Parses a line of git ls-tree output, which looks like
+//
+//	<mode> SP <type> SP <object> TAB <path>
+//
+// Returns false unless the entry is a file. Submodules and other entries
+// can't be blamed.
+func parseLsTreeBlob(line string) (string, bool) {
+	meta, path, ok := strings.Cut(line, "\t")
+	if !ok {
+		return "", false
+	}
+
+	fields := strings.Fields(meta)
+	if len(fields) < 2 || fields[1] != "blob" {
+		return "", false
+	}
+
+	return path, true
+}
+
+// Returns the commits responsible for the lines in the tree at rev, according
+// to git blame. Each commit has one file diff per file where its lines survive,
+// counting those lines as added. Lines the commit added that were later
+// changed or deleted aren't counted.
+//
+// Commits are ordered by author date, oldest first. This runs git blame on
+// every file under the given paths, one at a time, so it can be slow for big
+// trees. Submodules are skipped.
+func SurvivingCommits(
+	ctx context.Context,
+	rev string,
+	paths []string,
+) (_ []Commit, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error getting surviving lines: %w", err)
+		}
+	}()
+
+	files := []string{}
+
+	subprocess, err := RunLsTree(ctx, rev, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for line, err := range subprocess.StdoutLogLines() {
+		if err != nil {
+			return nil, err
+		}
+
+		if path, ok := parseLsTreeBlob(line); ok {
+			files = append(files, path)
+		}
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	commits := map[string]Commit{}
+	for _, path := range files {
+		subprocess, err := RunBlame(ctx, rev, path)
+		if err != nil {
+			return nil, err
+		}
+
+		survivingLines := map[string]int{} // Commit hash -> lines
+		for line, err := range parseBlame(subprocess.StdoutLines()) {
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := commits[line.hash]; !ok {
+				commits[line.hash] = line.commit
+			}
+			survivingLines[line.hash] += 1
+		}
+
+		err = subprocess.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		for hash, n := range survivingLines {
+			commit := commits[hash]
+			commit.FileDiffs = append(commit.FileDiffs, FileDiff{
+				Path:       path,
+				LinesAdded: n,
+			})
+			commits[hash] = commit
+		}
+	}
+
+	return slices.SortedFunc(maps.Values(commits), func(a, b Commit) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Hash, b.Hash)
+	}), nil
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
+)
+
+func TestParseBlame(t *testing.T) {
+	output := strings.TrimSpace(`
+29d4e8aa5ed1d1264d00f6e2a6dbb19831755651 1 1 2
+author Bob
+author-mail <bob@corp.com>
+author-time 1672920000
+author-tz +0100
+committer Alice
+committer-mail <alice@corp.com>
+committer-time 1672930000
+committer-tz +0000
+summary feat: change 1
+boundary
+filename src/f1.go
+	package main
+29d4e8aa5ed1d1264d00f6e2a6dbb19831755651 2 2
+author Bob
+author-mail <bob@corp.com>
+author-time 1672920000
+author-tz +0100
+committer Alice
+committer-mail <alice@corp.com>
+committer-time 1672930000
+committer-tz +0000
+summary feat: change 1
+boundary
+filename src/f1.go
+	
+	`)
+
+	lines, err := iterutils.Collect(
+		parseBlame(iterutils.WithoutErrors(slices.Values(
+			strings.Split(output+"\n\t", "\n"),
+		))),
+	)
+	if err != nil {
+		t.Fatalf("parseBlame() returned error: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %d", len(lines))
+	}
+
+	commit := lines[1].commit
+	if commit.Hash != "29d4e8aa5ed1d1264d00f6e2a6dbb19831755651" {
+		t.Errorf("wrong hash: %s", commit.Hash)
+	}
+	if commit.AuthorName != "Bob" || commit.AuthorEmail != "bob@corp.com" {
+		t.Errorf("wrong author: %s <%s>", commit.AuthorName, commit.AuthorEmail)
+	}
+	if commit.Subject != "feat: change 1" {
+		t.Errorf("wrong subject: %s", commit.Subject)
+	}
+	if !commit.Date.Equal(time.Unix(1672920000, 0)) {
+		t.Errorf("wrong author date: %v", commit.Date)
+	}
+	if _, offset := commit.Date.Zone(); offset != 60*60 {
+		t.Errorf("expected author date offset +0100 but got %d", offset)
+	}
+	if !commit.CommitterDate.Equal(time.Unix(1672930000, 0)) {
+		t.Errorf("wrong committer date: %v", commit.CommitterDate)
+	}
+}
+
+func TestParseLsTreeBlob(t *testing.T) {
+	tests := []struct {
+		line string
+		path string
+		ok   bool
+	}{
+		{
+			line: "100644 blob 8baef1b4abc478178b004d62031cf7fe6db6f903\tmain.go",
+			path: "main.go",
+			ok:   true,
+		},
+		{
+			line: "100644 blob 8baef1b4abc478178b004d62031cf7fe6db6f903\tdir/a b.go",
+			path: "dir/a b.go",
+			ok:   true,
+		},
+		{
+			line: "160000 commit 5e6a8c1b2f3e4d5c6b7a8f9e0d1c2b3a4f5e6d7c\tvendor/lib",
+			ok:   false,
+		},
+		{line: "", ok: false},
+	}
+
+	for _, test := range tests {
+		path, ok := parseLsTreeBlob(test.line)
+		if path != test.path || ok != test.ok {
+			t.Errorf(
+				"expected (%q, %v) for %q but got (%q, %v)",
+				test.path,
+				test.ok,
+				test.line,
+				path,
+				ok,
+			)
+		}
+	}
+}
@@ -14,8 +14,11 @@ import (
 )
 
 const (
-	logFormat     = "--pretty=format:%H%n%h%n%p%n%aN%n%aE%n%ad%n%s%n" // newline
-	logDiffFormat = "--pretty=format:%H%n%h%n%p%n%aN%n%aE%n%ad%n%s"
+	logFormat     = "--pretty=format:%H%n%h%n%P%n%aN%n%aE%n%ad%n%cd%n%s%n" + coAuthorsFormat + "%n" // newline
+	logDiffFormat = "--pretty=format:%H%n%h%n%P%n%aN%n%aE%n%ad%n%cd%n%s%n" + coAuthorsFormat
+
+	// Co-authored-by trailer values on one line, separated by 0x1F
+	coAuthorsFormat = "%(trailers:key=Co-authored-by,valueonly,separator=%x1f)"
 )
 
 type SubprocessErr struct {
@@ -74,7 +77,13 @@ func (s Subprocess) StdoutLines() iter.Seq2[string, error] {
 //
 // Lines are split on both newlines and NULLs.
 func (s Subprocess) StdoutLogLines() iter.Seq2[string, error] {
-	scanner := bufio.NewScanner(s.stdout)
+	return logLines(s.stdout)
+}
+
+// Returns a single-use iterator over git log output read from r, split on
+// both newlines and NULLs.
+func logLines(r io.Reader) iter.Seq2[string, error] {
+	scanner := bufio.NewScanner(r)
 
 	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
 		null_i := bytes.IndexByte(data, '\x00')
@@ -179,6 +188,13 @@ type LogFilters struct {
 	Until    string
 	Authors  []string
 	Nauthors []string
+
+	// Only follow the first parent of merges, as with git log --first-parent,
+	// so that the commits on merged branches are left out and each merge
+	// stands in for them. Merges are still skipped when tallying unless
+	// merges are counted, and only tally.TallyOpts.MergeDiffs credits a merge
+	// with the lines it landed.
+	FirstParent bool
 }
 
 // Turn into CLI args we can pass to `git log`
@@ -197,6 +213,10 @@ func (f LogFilters) ToArgs() []string {
 		args = append(args, "--author", author)
 	}
 
+	if f.FirstParent {
+		args = append(args, "--first-parent")
+	}
+
 	if len(f.Nauthors) > 0 {
 		args = append(args, "--perl-regexp")
 
@@ -230,7 +250,7 @@ func RunLog(
 			"log",
 			logDiffFormat,
 			"-z",
-			"--date=unix",
+			"--date=raw",
 			"--reverse",
 			"--numstat",
 			"--diff-merges=first-parent",
@@ -241,7 +261,7 @@ func RunLog(
 			"log",
 			logFormat,
 			"-z",
-			"--date=unix",
+			"--date=raw",
 			"--reverse",
 		}
 	}
@@ -275,7 +295,7 @@ func RunStdinLog(
 			"log",
 			logDiffFormat,
 			"-z",
-			"--date=unix",
+			"--date=raw",
 			"--stdin",
 			"--no-walk",
 			"--reverse",
@@ -288,7 +308,7 @@ func RunStdinLog(
 			"log",
 			logFormat,
 			"-z",
-			"--date=unix",
+			"--date=raw",
 			"--stdin",
 			"--no-walk",
 			"--reverse",
@@ -360,6 +380,68 @@ func RunRevList(
 	return subprocess, nil
 }
 
+// Runs git log to print the full hash and signature status of each commit, one
+// commit per line.
+func RunSignatureLog(
+	ctx context.Context,
+	revs []string,
+	paths []string,
+	filters LogFilters,
+) (*Subprocess, error) {
+	baseArgs := []string{
+		"log",
+		"--pretty=format:%H %G?",
+	}
+
+	filterArgs := filters.ToArgs()
+
+	var args []string
+	if len(paths) > 0 {
+		args = slices.Concat(baseArgs, filterArgs, revs, []string{"--"}, paths)
+	} else {
+		args = slices.Concat(baseArgs, filterArgs, revs)
+	}
+
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	return subprocess, nil
+}
+
+// Runs git ls-tree to list the entries in the tree at rev, recursing into
+// subtrees.
+func RunLsTree(
+	ctx context.Context,
+	rev string,
+	paths []string,
+) (*Subprocess, error) {
+	args := slices.Concat(
+		[]string{"ls-tree", "-r", "-z", rev, "--"},
+		paths,
+	)
+
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git ls-tree: %w", err)
+	}
+
+	return subprocess, nil
+}
+
+// Runs git blame on a single file as of rev.
+func RunBlame(ctx context.Context, rev string, path string) (*Subprocess, error) {
+	args := []string{"blame", "--line-porcelain", rev, "--", path}
+
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git blame: %w", err)
+	}
+
+	return subprocess, nil
+}
+
 func RunLsFiles(ctx context.Context, paths []string) (*Subprocess, error) {
 	baseArgs := []string{"ls-files", "--exclude-standard"}
 
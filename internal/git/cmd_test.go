@@ -0,0 +1,21 @@
+package git_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+func TestLogFiltersToArgsFirstParent(t *testing.T) {
+	filters := git.LogFilters{Since: "2024-01-01", FirstParent: true}
+	args := filters.ToArgs()
+	if !slices.Contains(args, "--first-parent") {
+		t.Errorf("expected --first-parent in args but got %v", args)
+	}
+
+	args = git.LogFilters{Since: "2024-01-01"}.ToArgs()
+	if slices.Contains(args, "--first-parent") {
+		t.Errorf("expected no --first-parent in args but got %v", args)
+	}
+}
@@ -11,18 +11,58 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Commit struct {
-	Hash        string
-	ShortHash   string
-	IsMerge     bool
-	AuthorName  string
-	AuthorEmail string
-	Date        time.Time
-	FileDiffs   []FileDiff
+	Hash          string
+	ShortHash     string
+	IsMerge       bool
+	NumParents    int      // Zero for a root commit
+	Parents       []string // Full hashes, first parent first
+	AuthorName    string
+	AuthorEmail   string
+	Date          time.Time // Author date, in the author's UTC offset
+	CommitterDate time.Time // In the committer's UTC offset
+	Subject       string    // First line of the commit message
+	CoAuthors     []Author  // From Co-authored-by trailers
+	FileDiffs     []FileDiff
+
+	// Zero unless looked up separately; see Signatures()
+	Signature SignatureStatus
+}
+
+// Whether a commit is signed and whether the signature checks out, as given by
+// git log's %G? placeholder.
+type SignatureStatus byte
+
+const (
+	GoodSignature            SignatureStatus = 'G'
+	UnknownValiditySignature SignatureStatus = 'U' // Good, but key not trusted
+	ExpiredSignature         SignatureStatus = 'X'
+	ExpiredKeySignature      SignatureStatus = 'Y'
+	RevokedKeySignature      SignatureStatus = 'R'
+	BadSignature             SignatureStatus = 'B'
+	UncheckedSignature       SignatureStatus = 'E' // E.g. the key is missing
+	NoSignature              SignatureStatus = 'N'
+)
+
+// Whether the commit has a signature at all, good or not.
+func (s SignatureStatus) Signed() bool {
+	return s != 0 && s != NoSignature
+}
+
+// Whether the commit has a good signature from a trusted key.
+func (s SignatureStatus) Verified() bool {
+	return s == GoodSignature
+}
+
+// An identity given in a commit trailer.
+type Author struct {
+	Name  string
+	Email string
 }
 
 func (c Commit) Name() string {
@@ -49,11 +89,26 @@ func (c Commit) String() string {
 // A file that was changed in a Commit.
 type FileDiff struct {
 	Path         string
+	OldPath      string // Path before the change if the file was renamed
 	LinesAdded   int
 	LinesRemoved int
 }
 
+func (d FileDiff) IsRename() bool {
+	return d.OldPath != ""
+}
+
 func (d FileDiff) String() string {
+	if d.IsRename() {
+		return fmt.Sprintf(
+			"{ path:\"%s\" old:\"%s\" added:%d removed:%d }",
+			d.Path,
+			d.OldPath,
+			d.LinesAdded,
+			d.LinesRemoved,
+		)
+	}
+
 	return fmt.Sprintf(
 		"{ path:\"%s\" added:%d removed:%d }",
 		d.Path,
@@ -90,6 +145,18 @@ func CommitsWithOpts(
 	return commits, closer, nil
 }
 
+// Returns an iterator over commits parsed from saved git log output, e.g. for
+// offline analysis or test fixtures.
+//
+// The output should be in the format git-who asks for when it runs git log
+// itself; see RunLog(). That is, from something like:
+//
+//	git log '--pretty=format:%H%n%h%n%P%n%aN%n%aE%n%ad%n%cd%n%s%n%(trailers:key=Co-authored-by,valueonly,separator=%x1f)' \
+//	    -z --date=raw --reverse --numstat --diff-merges=first-parent
+func CommitsFromLog(r io.Reader) iter.Seq2[Commit, error] {
+	return ParseCommits(logLines(r))
+}
+
 func RevList(
 	ctx context.Context,
 	revranges []string,
@@ -126,6 +193,70 @@ func RevList(
 	return revs, nil
 }
 
+// Returns the signature status of each commit git log would list for the
+// given revisions, paths and filters, by full hash.
+//
+// Git has to check every signature, so this can be slow for a long history of
+// signed commits. That's why RunLog() doesn't ask for them.
+func Signatures(
+	ctx context.Context,
+	revs []string,
+	paths []string,
+	filters LogFilters,
+) (_ map[string]SignatureStatus, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error getting commit signatures: %w", err)
+		}
+	}()
+
+	subprocess, err := RunSignatureLog(ctx, revs, paths, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := map[string]SignatureStatus{}
+	for line, err := range subprocess.StdoutLines() {
+		if err != nil {
+			return nil, err
+		}
+
+		hash, status, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok || len(status) != 1 {
+			return nil, fmt.Errorf("unexpected signature output: %s", line)
+		}
+
+		statuses[hash] = SignatureStatus(status[0])
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// Returns the commits with their Signature filled in from the given statuses,
+// e.g. from Signatures(). Commits missing from the statuses are left as they
+// are.
+func WithSignatures(
+	commits iter.Seq2[Commit, error],
+	statuses map[string]SignatureStatus,
+) iter.Seq2[Commit, error] {
+	return func(yield func(Commit, error) bool) {
+		for commit, err := range commits {
+			if status, ok := statuses[commit.Hash]; ok {
+				commit.Signature = status
+			}
+
+			if !yield(commit, err) {
+				return
+			}
+		}
+	}
+}
+
 func GetRoot() (_ string, err error) {
 	defer func() {
 		if err != nil {
@@ -159,6 +290,142 @@ func GetRoot() (_ string, err error) {
 	return root, nil
 }
 
+// Returns the full hash of the commit the given revision points to.
+func ResolveRev(rev string) (_ string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("failed to resolve revision \"%s\": %w", rev, err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args := []string{
+		"rev-parse",
+		"--verify",
+		"--end-of-options",
+		rev + "^{commit}",
+	}
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := io.ReadAll(subprocess.stdout)
+	if err != nil {
+		return "", err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return "", err
+	}
+
+	hash := strings.TrimSpace(string(b))
+	return hash, nil
+}
+
+// A tag along with the time it was created. For a lightweight tag, that's the
+// committer date of the tagged commit.
+type Tag struct {
+	Name string
+	Time time.Time
+}
+
+// Returns every tag in the repository, oldest first.
+func Tags() (_ []Tag, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error listing tags: %w", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args := []string{
+		"tag",
+		"--sort=creatordate",
+		"--format=%(creatordate:unix) %(refname:strip=2)",
+	}
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []Tag{}
+	for line, err := range subprocess.StdoutLines() {
+		if err != nil {
+			return nil, err
+		}
+
+		unix, name, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected tag output: %s", line)
+		}
+
+		i, err := strconv.ParseInt(unix, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, Tag{Name: name, Time: time.Unix(i, 0)})
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Parses a date in any format accepted by git log --since.
+//
+// Git is happy to parse nonsense as the current time, so this never fails on
+// account of the input; only if git itself fails.
+func ParseDate(date string) (_ time.Time, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("failed to parse date \"%s\": %w", date, err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args := []string{"rev-parse", "--since=" + date}
+	subprocess, err := run(ctx, args, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	b, err := io.ReadAll(subprocess.stdout)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Output looks like --max-age=1672544838
+	out := strings.TrimSpace(string(b))
+	_, unix, ok := strings.Cut(out, "=")
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected rev-parse output: %s", out)
+	}
+
+	i, err := strconv.Atoi(unix)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(i), 0), nil
+}
+
 // Returns all paths in the working tree under the given paths.
 func WorkingTreeFiles(paths []string) (_ map[string]bool, err error) {
 	defer func() {
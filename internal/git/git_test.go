@@ -2,8 +2,12 @@ package git_test
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
 )
@@ -206,3 +210,86 @@ func TestParseWholeLog(t *testing.T) {
 		t.Errorf("encountered error cleaning up: %v", err)
 	}
 }
+
+func TestCommitsFromLog(t *testing.T) {
+	log := strings.Join([]string{
+		"59d090ce20ba45ef41b44551abcd056c35cf4fb7\n" +
+			"59d090c\n" +
+			"e34dd4f\n" +
+			"Alice\n" +
+			"alice@corp.com\n" +
+			"1714564800 +0000\n" +
+			"1714564800 +0000\n" +
+			"feat: change 8\n" +
+			"Dan <dan@corp.com>\x1fEve <eve@corp.com>\n" +
+			"1\t0\tREADME.md",
+		"24\t0\tsrc/f8.go",
+		"",
+		"18167edfa74db6cb737ee6e87bbb587d440fabb4\n" +
+			"18167ed\n" +
+			"59d090c\n" +
+			"Carol\n" +
+			"carol@corp.com\n" +
+			"1675252800 +0000\n" +
+			"1718452800 +0000\n" +
+			"fix: rebased\n" +
+			"\n" +
+			"1\t0\tz.txt",
+	}, "\x00") + "\x00"
+
+	commits, err := iterutils.Collect(git.CommitsFromLog(strings.NewReader(log)))
+	if err != nil {
+		t.Fatalf("CommitsFromLog() returned error: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits but found %d", len(commits))
+	}
+
+	alice := commits[0]
+	if alice.AuthorName != "Alice" || alice.Subject != "feat: change 8" {
+		t.Errorf("first commit is wrong: %v", alice)
+	}
+	if len(alice.FileDiffs) != 2 || alice.FileDiffs[1].LinesAdded != 24 {
+		t.Errorf("expected 2 file diffs but got %v", alice.FileDiffs)
+	}
+
+	expected := []git.Author{
+		{Name: "Dan", Email: "dan@corp.com"},
+		{Name: "Eve", Email: "eve@corp.com"},
+	}
+	if diff := cmp.Diff(expected, alice.CoAuthors); diff != "" {
+		t.Errorf("co-authors are wrong:\n%s", diff)
+	}
+
+	carol := commits[1]
+	if carol.ShortHash != "18167ed" || len(carol.FileDiffs) != 1 {
+		t.Errorf("second commit is wrong: %v", carol)
+	}
+	if carol.CoAuthors != nil {
+		t.Errorf("expected no co-authors but got %v", carol.CoAuthors)
+	}
+	if !carol.CommitterDate.After(carol.Date) {
+		t.Errorf("expected committer date after author date for %v", carol)
+	}
+}
+
+func TestWithSignatures(t *testing.T) {
+	commits := []git.Commit{{Hash: "a"}, {Hash: "b"}}
+	statuses := map[string]git.SignatureStatus{"a": git.GoodSignature}
+
+	signed, err := iterutils.Collect(git.WithSignatures(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		statuses,
+	))
+	if err != nil {
+		t.Fatalf("WithSignatures() returned error: %v", err)
+	}
+
+	if !signed[0].Signature.Verified() {
+		t.Errorf("expected first commit to be verified")
+	}
+	if signed[1].Signature.Signed() {
+		t.Errorf("expected second commit to be unsigned")
+	}
+}
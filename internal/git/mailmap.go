@@ -0,0 +1,155 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Canonical identity for an author as given by a mailmap entry.
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+	commitName  string // Only match commits with this name if non-empty
+}
+
+// Maps commit author identities to canonical identities.
+//
+// Git already applies the repo's .mailmap when we ask for %aN and %aE in git
+// log. This is for applying a mailmap file from some other location after the
+// fact, e.g. to commits we previously read from the cache.
+//
+// See gitmailmap(5) for the file format.
+type Mailmap struct {
+	entries map[string][]mailmapEntry // Lowercased commit email -> entries
+}
+
+// Reads a mailmap file from the given path.
+func ReadMailmap(path string) (_ Mailmap, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading mailmap file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Mailmap{}, err
+	}
+	defer f.Close()
+
+	return ParseMailmap(f)
+}
+
+func ParseMailmap(r io.Reader) (Mailmap, error) {
+	m := Mailmap{entries: map[string][]mailmapEntry{}}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		names, emails, err := splitMailmapLine(line)
+		if err != nil {
+			return m, err
+		}
+
+		var entry mailmapEntry
+		var commitEmail string
+		switch len(emails) {
+		case 1:
+			// Proper Name <commit@email>
+			entry.properName = names[0]
+			commitEmail = emails[0]
+		case 2:
+			// [Proper Name] <proper@email> [Commit Name] <commit@email>
+			entry.properName = names[0]
+			entry.properEmail = emails[0]
+			entry.commitName = names[1]
+			commitEmail = emails[1]
+		default:
+			return m, fmt.Errorf("malformed mailmap line: \"%s\"", line)
+		}
+
+		key := strings.ToLower(commitEmail)
+		m.entries[key] = append(m.entries[key], entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return m, fmt.Errorf("error while scanning: %w", err)
+	}
+
+	return m, nil
+}
+
+// Splits a mailmap line into the names preceding each email and the emails.
+func splitMailmapLine(line string) (names []string, emails []string, err error) {
+	rest := line
+	for len(rest) > 0 {
+		start := strings.IndexByte(rest, '<')
+		end := strings.IndexByte(rest, '>')
+		if start < 0 || end < start {
+			return nil, nil, fmt.Errorf("malformed mailmap line: \"%s\"", line)
+		}
+
+		names = append(names, strings.TrimSpace(rest[:start]))
+		emails = append(emails, rest[start+1:end])
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	return names, emails, nil
+}
+
+// Returns the canonical name and email for the given identity.
+func (m Mailmap) Resolve(name string, email string) (string, string) {
+	entries, ok := m.entries[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+
+	// Entries that also match on name take precedence
+	var match *mailmapEntry
+	for i, entry := range entries {
+		if entry.commitName == "" && match == nil {
+			match = &entries[i]
+		} else if entry.commitName != "" && entry.commitName == name {
+			match = &entries[i]
+			break
+		}
+	}
+
+	if match == nil {
+		return name, email
+	}
+
+	if match.properName != "" {
+		name = match.properName
+	}
+	if match.properEmail != "" {
+		email = match.properEmail
+	}
+
+	return name, email
+}
+
+// Returns the commit with its author identity canonicalized.
+func (m Mailmap) Apply(commit Commit) Commit {
+	if len(m.entries) == 0 {
+		return commit
+	}
+
+	commit.AuthorName, commit.AuthorEmail = m.Resolve(
+		commit.AuthorName,
+		commit.AuthorEmail,
+	)
+	return commit
+}
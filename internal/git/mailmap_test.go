@@ -0,0 +1,60 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+const testMailmap = `
+# Comments and blank lines are ignored
+
+Alice Smith <alice@corp.com>
+<bob@corp.com> <bob@personal.dev>
+Alice Smith <alice@corp.com> <alice@personal.dev>
+Jim Jones <jim@corp.com> jimbo <jim@old.com>
+`
+
+func TestMailmapResolve(t *testing.T) {
+	mailmap, err := git.ParseMailmap(strings.NewReader(testMailmap))
+	if err != nil {
+		t.Fatalf("ParseMailmap() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		expName  string
+		expEmail string
+	}{
+		{"alice", "alice@corp.com", "Alice Smith", "alice@corp.com"},
+		{"bob", "Bob@Personal.dev", "bob", "bob@corp.com"},
+		{"alice", "alice@personal.dev", "Alice Smith", "alice@corp.com"},
+		{"jimbo", "jim@old.com", "Jim Jones", "jim@corp.com"},
+		{"james", "jim@old.com", "james", "jim@old.com"},
+		{"zed", "zed@corp.com", "zed", "zed@corp.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.email, func(t *testing.T) {
+			name, email := mailmap.Resolve(test.name, test.email)
+			if name != test.expName || email != test.expEmail {
+				t.Errorf(
+					"expected %s <%s> but got %s <%s>",
+					test.expName,
+					test.expEmail,
+					name,
+					email,
+				)
+			}
+		})
+	}
+}
+
+func TestParseMailmapMalformed(t *testing.T) {
+	_, err := git.ParseMailmap(strings.NewReader("Alice <alice@corp.com"))
+	if err == nil {
+		t.Errorf("expected ParseMailmap() to return error")
+	}
+}
@@ -146,6 +146,64 @@ func allowCommit(commit Commit, now time.Time) bool {
 	return true
 }
 
+// Parses a date as given by git log --date=raw, e.g. "1711962000 +0900".
+//
+// The returned time keeps the original UTC offset, so that the commit's local
+// time of day is the one the author saw. A plain Unix timestamp is accepted
+// too, in which case the time is local.
+func parseRawDate(s string) (time.Time, error) {
+	secs, offset, hasOffset := strings.Cut(s, " ")
+
+	i, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := time.Unix(i, 0)
+	if !hasOffset {
+		return t, nil
+	}
+
+	if len(offset) != 5 || (offset[0] != '+' && offset[0] != '-') {
+		return time.Time{}, fmt.Errorf("malformed UTC offset \"%s\"", offset)
+	}
+
+	hours, err := strconv.Atoi(offset[1:3])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed UTC offset \"%s\"", offset)
+	}
+	minutes, err := strconv.Atoi(offset[3:])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed UTC offset \"%s\"", offset)
+	}
+
+	seconds := hours*60*60 + minutes*60
+	if offset[0] == '-' {
+		seconds = -seconds
+	}
+
+	return t.In(time.FixedZone(offset, seconds)), nil
+}
+
+// Parses Co-authored-by trailer values separated by 0x1F, each of which
+// should look like "Name <email>".
+func parseCoAuthors(line string) []Author {
+	if line == "" {
+		return nil
+	}
+
+	coAuthors := []Author{}
+	for _, value := range strings.Split(line, "\x1f") {
+		name, email, _ := strings.Cut(value, "<")
+		coAuthors = append(coAuthors, Author{
+			Name:  strings.TrimSpace(name),
+			Email: strings.TrimSpace(strings.TrimSuffix(email, ">")),
+		})
+	}
+
+	return coAuthors
+}
+
 // Turns an iterator over lines from git log into an iterator of commits
 func ParseCommits(lines iter.Seq2[string, error]) iter.Seq2[Commit, error] {
 	return func(yield func(Commit, error) bool) {
@@ -167,7 +225,7 @@ func ParseCommits(lines iter.Seq2[string, error]) iter.Seq2[Commit, error] {
 				return
 			}
 
-			done := linesThisCommit >= 7 && (len(line) == 0 || isRev(line))
+			done := linesThisCommit >= 9 && (len(line) == 0 || isRev(line))
 			if done {
 				if allowCommit(commit, now) {
 					if !yield(commit, nil) {
@@ -190,14 +248,15 @@ func ParseCommits(lines iter.Seq2[string, error]) iter.Seq2[Commit, error] {
 			case linesThisCommit == 1:
 				commit.ShortHash = line
 			case linesThisCommit == 2:
-				parts := strings.Split(line, " ")
-				commit.IsMerge = len(parts) > 1
+				commit.Parents = strings.Fields(line)
+				commit.NumParents = len(commit.Parents)
+				commit.IsMerge = commit.NumParents > 1
 			case linesThisCommit == 3:
 				commit.AuthorName = line
 			case linesThisCommit == 4:
 				commit.AuthorEmail = line
 			case linesThisCommit == 5:
-				i, err := strconv.Atoi(line)
+				date, err := parseRawDate(line)
 				if err != nil {
 					yield(
 						commit,
@@ -210,9 +269,26 @@ func ParseCommits(lines iter.Seq2[string, error]) iter.Seq2[Commit, error] {
 					return
 				}
 
-				commit.Date = time.Unix(int64(i), 0)
+				commit.Date = date
 			case linesThisCommit == 6:
-				break // Used to parse subject here; no longer
+				date, err := parseRawDate(line)
+				if err != nil {
+					yield(
+						commit,
+						fmt.Errorf(
+							"error parsing committer date from commit %s: %w",
+							commit.Name(),
+							err,
+						),
+					)
+					return
+				}
+
+				commit.CommitterDate = date
+			case linesThisCommit == 7:
+				commit.Subject = line
+			case linesThisCommit == 8:
+				commit.CoAuthors = parseCoAuthors(line)
 			default:
 				// file diff line
 				parts := strings.Split(strings.Trim(line, "\t"), "\t")
@@ -252,6 +328,8 @@ func ParseCommits(lines iter.Seq2[string, error]) iter.Seq2[Commit, error] {
 					}
 				} else if len(parts) == 1 {
 					if len(diff.Path) > 0 {
+						// Second path for a rename is the destination
+						diff.OldPath = diff.Path
 						diff.Path = parts[0]
 						commit.FileDiffs = append(commit.FileDiffs, diff)
 						diff = FileDiff{}
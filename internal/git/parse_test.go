@@ -0,0 +1,152 @@
+package git_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
+)
+
+func TestParseCommitsParents(t *testing.T) {
+	lines := []string{
+		"c9a1f3e0b7d2c9a1f3e0b7d2c9a1f3e0b7d2c9a1",
+		"c9a1f3e",
+		"a1b2c3d e4f5a6b",
+		"Bob",
+		"bob@mail.com",
+		"1711962000",
+		"1711962000",
+		"Merge branch 'feature'",
+		"", // No co-authors
+		"",
+		"a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+		"a1b2c3d",
+		"",
+		"Alice",
+		"alice@mail.com",
+		"1711875600",
+		"1711875600",
+		"Initial commit",
+		"", // No co-authors
+		"3\t0\tmain.go",
+		"",
+	}
+
+	commits, err := iterutils.Collect(
+		git.ParseCommits(iterutils.WithoutErrors(slices.Values(lines))),
+	)
+	if err != nil {
+		t.Fatalf("ParseCommits() returned error: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits but got %d", len(commits))
+	}
+
+	merge := commits[0]
+	if merge.NumParents != 2 || !merge.IsMerge {
+		t.Errorf(
+			"expected merge commit with 2 parents but got %d parents",
+			merge.NumParents,
+		)
+	}
+	if diff := cmp.Diff([]string{"a1b2c3d", "e4f5a6b"}, merge.Parents); diff != "" {
+		t.Errorf("merge commit parents are wrong:\n%s", diff)
+	}
+
+	root := commits[1]
+	if root.NumParents != 0 || root.IsMerge {
+		t.Errorf(
+			"expected root commit with 0 parents but got %d parents",
+			root.NumParents,
+		)
+	}
+	if root.Subject != "Initial commit" {
+		t.Errorf("expected subject \"Initial commit\" but got \"%s\"", root.Subject)
+	}
+}
+
+func TestParseCommitsRename(t *testing.T) {
+	lines := []string{
+		"c9a1f3e0b7d2c9a1f3e0b7d2c9a1f3e0b7d2c9a1",
+		"c9a1f3e",
+		"a1b2c3d",
+		"Bob",
+		"bob@mail.com",
+		"1711962000",
+		"1711962000",
+		"Rename foo",
+		"", // No co-authors
+		"1\t0\t",
+		"foo.go",
+		"bar/foo.go",
+		"2\t1\tmain.go",
+		"",
+	}
+
+	commits, err := iterutils.Collect(
+		git.ParseCommits(iterutils.WithoutErrors(slices.Values(lines))),
+	)
+	if err != nil {
+		t.Fatalf("ParseCommits() returned error: %v", err)
+	}
+
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit but got %d", len(commits))
+	}
+
+	expected := []git.FileDiff{
+		{Path: "bar/foo.go", OldPath: "foo.go", LinesAdded: 1},
+		{Path: "main.go", LinesAdded: 2, LinesRemoved: 1},
+	}
+	if diff := cmp.Diff(expected, commits[0].FileDiffs); diff != "" {
+		t.Errorf("file diffs are wrong:\n%s", diff)
+	}
+}
+
+func TestParseCommitsDateOffset(t *testing.T) {
+	lines := []string{
+		"c9a1f3e0b7d2c9a1f3e0b7d2c9a1f3e0b7d2c9a1",
+		"c9a1f3e",
+		"",
+		"Bob",
+		"bob@mail.com",
+		"1711893600 +0900",
+		"1711893600 -0130",
+		"Late night commit",
+		"", // No co-authors
+		"",
+	}
+
+	commits, err := iterutils.Collect(
+		git.ParseCommits(iterutils.WithoutErrors(slices.Values(lines))),
+	)
+	if err != nil {
+		t.Fatalf("ParseCommits() returned error: %v", err)
+	}
+
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit but got %d", len(commits))
+	}
+
+	commit := commits[0]
+	if !commit.Date.Equal(time.Unix(1711893600, 0)) {
+		t.Errorf("author date is the wrong instant: %v", commit.Date)
+	}
+
+	// 23:00 on March 31 UTC+9 shouldn't be shifted into another day
+	year, month, day := commit.Date.Date()
+	if year != 2024 || month != time.March || day != 31 ||
+		commit.Date.Hour() != 23 {
+		t.Errorf("expected author date in UTC+9 but got %v", commit.Date)
+	}
+
+	_, offset := commit.CommitterDate.Zone()
+	if offset != -(60+30)*60 {
+		t.Errorf("expected committer date offset -0130 but got %d", offset)
+	}
+}
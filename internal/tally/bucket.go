@@ -1,21 +1,31 @@
 package tally
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"maps"
+	"math"
 	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
+	"github.com/sinclairtarget/git-who/internal/utils/timeutils"
 )
 
 type TimeBucket struct {
 	Name       string
-	Time       time.Time
+	Time       time.Time  // Start of the bucket
+	EndTime    time.Time  // Exclusive; zero if the resolution is unknown
 	Tally      FinalTally // Winning author's tally
+	RunnerUp   FinalTally // Second place; zero if fewer than two authors
 	TotalTally FinalTally // Overall tally for all authors
+	Partial    bool       // Runs past the end of the timeline; see ToTimeline()
 	tallies    map[string]Tally
 }
 
@@ -27,59 +37,346 @@ func newBucket(name string, t time.Time) TimeBucket {
 	}
 }
 
-func (b TimeBucket) Value(mode TallyMode) int {
-	switch mode {
-	case CommitMode:
-		return b.Tally.Commits
-	case FilesMode:
-		return b.Tally.FileCount
-	case LinesMode:
-		return b.Tally.LinesAdded + b.Tally.LinesRemoved
-	default:
-		panic("unrecognized tally mode in switch")
+func (b TimeBucket) Value(mode TallyMode) int64 {
+	return tallyValue(b.Tally, mode)
+}
+
+func (b TimeBucket) TotalValue(mode TallyMode) int64 {
+	return tallyValue(b.TotalTally, mode)
+}
+
+// Lines added and removed by the winner, e.g. for drawing diverging bars. The
+// bucket should have been ranked first.
+func (b TimeBucket) WinnerLines() (added int64, removed int64) {
+	return b.Tally.LinesAdded, b.Tally.LinesRemoved
+}
+
+// Lines added and removed by all authors in the bucket. The bucket should have
+// been ranked first.
+func (b TimeBucket) TotalLines() (added int64, removed int64) {
+	return b.TotalTally.LinesAdded, b.TotalTally.LinesRemoved
+}
+
+// Lines removed per line added by all authors in the bucket, e.g. to spot
+// periods of refactoring or reverts. Returns +Inf if lines were removed but
+// none added, and zero if no lines changed. The bucket should have been
+// ranked first.
+func (b TimeBucket) ChurnRatio() float64 {
+	added, removed := b.TotalLines()
+	if added == 0 {
+		if removed == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+
+	return float64(removed) / float64(added)
+}
+
+// Gini coefficient of the authors' values in the bucket for the given mode, as
+// a measure of how concentrated the work is. Zero means everyone contributed
+// equally; one author doing everything among n gives (n-1)/n, which
+// approaches 1. Values are taken by magnitude, as with FoldBelow(). The tally
+// under OthersKey is left out. Returns zero if there's nothing to compare.
+func (b TimeBucket) Gini(mode TallyMode) float64 {
+	values := []float64{}
+	for key, tally := range b.tallies {
+		if key != OthersKey {
+			value := tallyValue(tally.Final(), mode)
+			values = append(values, math.Abs(float64(value)))
+		}
+	}
+	slices.Sort(values)
+
+	var sum, weighted float64
+	for i, value := range values {
+		sum += value
+		weighted += float64(i+1) * value
+	}
+	if sum == 0 {
+		return 0
 	}
+
+	n := float64(len(values))
+	return 2*weighted/(n*sum) - (n+1)/n
+}
+
+// How far the winner is ahead of the runner-up, e.g. to tell a dominant winner
+// from a narrow lead. Zero if there are fewer than two authors. The bucket
+// should have been ranked using the same mode.
+func (b TimeBucket) WinnerMargin(mode TallyMode) int64 {
+	if b.RunnerUp.AuthorName == "" && b.RunnerUp.AuthorEmail == "" {
+		return 0
+	}
+
+	return b.Value(mode) - tallyValue(b.RunnerUp, mode)
 }
 
-func (b TimeBucket) TotalValue(mode TallyMode) int {
+// Line counts are int64 so that sums over a big history can't overflow, even
+// on 32-bit platforms.
+func tallyValue(t FinalTally, mode TallyMode) int64 {
 	switch mode {
 	case CommitMode:
-		return b.TotalTally.Commits
+		return int64(t.Commits)
 	case FilesMode:
-		return b.TotalTally.FileCount
+		return int64(t.FileCount)
 	case LinesMode:
-		return b.TotalTally.LinesAdded + b.TotalTally.LinesRemoved
+		return t.LinesAdded + t.LinesRemoved
+	case NetLinesMode:
+		return t.LinesAdded - t.LinesRemoved
+	case ChurnMode:
+		return int64(math.Round(t.Churn))
 	default:
 		panic("unrecognized tally mode in switch")
 	}
 }
 
-func (a TimeBucket) Combine(b TimeBucket) TimeBucket {
+// Returns an iterator over the finalized tally for every author in the bucket,
+// in order of author key. Use Rank() for tallies ordered by mode.
+func (b TimeBucket) Tallies() iter.Seq[FinalTally] {
+	return func(yield func(FinalTally) bool) {
+		for _, key := range slices.Sorted(maps.Keys(b.tallies)) {
+			if !yield(b.tallies[key].Final()) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the finalized tallies of the top n authors in the bucket, ordered
+// by mode. Returns every author's tally if there are fewer than n.
+func (b TimeBucket) TopN(n int, mode TallyMode) []FinalTally {
+	return b.TopNFunc(n, func(x, y FinalTally) int {
+		return x.Compare(y, mode)
+	})
+}
+
+// Like TopN(), but orders tallies using cmp. See RankFunc().
+func (b TimeBucket) TopNFunc(
+	n int,
+	cmp func(x, y FinalTally) int,
+) []FinalTally {
+	ranked := RankFunc(b.tallies, cmp)
+	if n < len(ranked) {
+		ranked = ranked[:n]
+	}
+
+	return ranked
+}
+
+// Returns a human-readable dump of every author's tally in the bucket, in
+// order of author key. Useful for figuring out why an author won a bucket.
+func (b TimeBucket) Debug() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)\n", b.Name, b.Time.Format(time.RFC3339))
+
+	for _, key := range slices.Sorted(maps.Keys(b.tallies)) {
+		t := b.tallies[key].Final()
+		fmt.Fprintf(
+			&sb,
+			"  %s: name=%q email=%q commits=%d added=%d removed=%d "+
+				"files=%d churn=%g first=%s last=%s\n",
+			key,
+			t.AuthorName,
+			t.AuthorEmail,
+			t.Commits,
+			t.LinesAdded,
+			t.LinesRemoved,
+			t.FileCount,
+			t.Churn,
+			t.FirstCommitTime.Format(time.RFC3339),
+			t.LastCommitTime.Format(time.RFC3339),
+		)
+	}
+
+	return sb.String()
+}
+
+// Returns the number of commits tallied in the bucket, regardless of mode.
+//
+// Unlike TotalTally, this does not require the bucket to have been ranked.
+func (b TimeBucket) CommitCount() int {
+	count := 0
+	for _, tally := range b.tallies {
+		count += tally.numTallied
+	}
+
+	return count
+}
+
+// A commit tallied in a bucket, kept with TallyOpts.RetainCommits.
+type CommitRef struct {
+	Hash    string
+	Subject string
+	Time    time.Time // Commit time used to place it on the timeline
+}
+
+// Returns the commits tallied in the bucket, oldest first, e.g. to list the
+// commits behind a bar. Commits credited to more than one tally, e.g. through
+// co-authors, are only listed once. Empty unless the bucket was tallied with
+// TallyOpts.RetainCommits.
+func (b TimeBucket) Commits() []CommitRef {
+	seen := map[string]bool{}
+	commits := []CommitRef{}
+	for _, tally := range b.tallies {
+		for _, commit := range tally.commits {
+			if !seen[commit.Hash] {
+				seen[commit.Hash] = true
+				commits = append(commits, commit)
+			}
+		}
+	}
+
+	slices.SortFunc(commits, func(a, b CommitRef) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Hash, b.Hash)
+	})
+	return commits
+}
+
+// Whether no commits were tallied in the bucket.
+func (b TimeBucket) isEmpty() bool {
+	return len(b.tallies) == 0
+}
+
+// Returns the number of distinct authors, as identified by TallyOpts.Key, who
+// contributed to the bucket.
+func (b TimeBucket) AuthorCount() int {
+	return len(b.tallies)
+}
+
+// Returns the proportion of the bucket's total value that belongs to the
+// winning author. Returns zero for an empty bucket.
+func (b TimeBucket) WinnerShare(mode TallyMode) float64 {
+	total := b.TotalValue(mode)
+	if total == 0 {
+		return 0
+	}
+
+	return float64(b.Value(mode)) / float64(total)
+}
+
+// Combines the per-author tallies of two buckets covering the same time.
+//
+// Returns an error if the buckets do not match, which usually means they were
+// generated using different resolutions.
+func (a TimeBucket) Combine(b TimeBucket) (TimeBucket, error) {
 	if a.Name != b.Name {
-		panic("cannot combine buckets whose names do not match")
+		return a, fmt.Errorf(
+			"cannot combine buckets whose names do not match: \"%s\" and \"%s\"",
+			a.Name,
+			b.Name,
+		)
 	}
 
-	if a.Time != b.Time {
-		panic("cannot combine buckets whose times do not match")
+	if !a.Time.Equal(b.Time) {
+		return a, fmt.Errorf(
+			"cannot combine buckets whose times do not match: %v and %v",
+			a.Time,
+			b.Time,
+		)
 	}
 
+	// Copy so that we don't modify either input
 	merged := a
+	merged.tallies = make(map[string]Tally, len(a.tallies))
+	for key, tally := range a.tallies {
+		merged.tallies[key] = tally.clone()
+	}
+
+	return merged.merge(b), nil
+}
+
+// Combines tallies without checking that the buckets match.
+//
+// This modifies the tallies in a, so a must not share them with any other
+// bucket. Tallies from b are copied.
+func (a TimeBucket) merge(b TimeBucket) TimeBucket {
 	for key, tally := range b.tallies {
 		existing, ok := a.tallies[key]
 		if ok {
-			merged.tallies[key] = existing.Combine(tally)
+			a.tallies[key] = existing.Combine(tally)
 		} else {
-			merged.tallies[key] = tally
+			a.tallies[key] = tally.clone()
 		}
 	}
 
-	return merged
+	return a
 }
 
+// Picks the winner and runner-up in the bucket according to mode and totals
+// up every author's tally.
+//
+// In FilesMode, authors whose commits touched no files can't place, so a
+// bucket with only empty commits or submodule bumps has a zero tally for its
+// winner. Their commits still count toward TotalTally.
 func (b TimeBucket) Rank(mode TallyMode) TimeBucket {
+	b = b.RankFunc(func(x, y FinalTally) int {
+		return x.Compare(y, mode)
+	})
+	b.Tally = placing(b.Tally, mode)
+	b.RunnerUp = placing(b.RunnerUp, mode)
+	return b
+}
+
+// Like Rank(), but picks the winner and runner-up from the authors whose keys
+// aren't in exclude, e.g. to find the top contributor other than the
+// maintainers. The winner is a zero tally if no one else contributed.
+// TotalTally still counts everyone. Add OthersKey to exclude to leave out the
+// authors folded by Prune().
+func (b TimeBucket) RankExcluding(
+	mode TallyMode,
+	exclude map[string]bool,
+) TimeBucket {
+	b = b.Rank(mode)
+
+	kept := map[string]Tally{}
+	for key, tally := range b.tallies {
+		if !exclude[key] {
+			kept[key] = tally
+		}
+	}
+
+	b.Tally = FinalTally{}
+	b.RunnerUp = FinalTally{}
+	if ranked := Rank(kept, mode); len(ranked) > 0 {
+		b.Tally = placing(ranked[0], mode)
+		if len(ranked) > 1 {
+			b.RunnerUp = placing(ranked[1], mode)
+		}
+	}
+
+	return b
+}
+
+// Returns the tally, or a zero tally if it touched no files and the mode is
+// FilesMode.
+func placing(t FinalTally, mode TallyMode) FinalTally {
+	if mode == FilesMode && t.FileCount == 0 && t.FileShare == 0 {
+		return FinalTally{}
+	}
+
+	return t
+}
+
+// Like Rank(), but picks the winner using cmp. See RankFunc().
+func (b TimeBucket) RankFunc(cmp func(x, y FinalTally) int) TimeBucket {
 	if len(b.tallies) > 0 {
-		b.Tally = Rank(b.tallies, mode)[0]
+		ranked := RankFunc(b.tallies, cmp)
+		b.Tally = ranked[0]
+		b.RunnerUp = FinalTally{}
+		if len(ranked) > 1 {
+			b.RunnerUp = ranked[1]
+		}
 
-		var runningTally Tally
+		// Start with our own sets so that we don't union into an author's sets
+		runningTally := Tally{
+			commitset:       map[string]bool{},
+			fileset:         map[string]bool{},
+			firstCommitTime: time.Unix(1<<62, 0),
+		}
 		for _, tally := range b.tallies {
 			runningTally = runningTally.Combine(tally)
 		}
@@ -89,106 +386,1459 @@ func (b TimeBucket) Rank(mode TallyMode) TimeBucket {
 	return b
 }
 
-type TimeSeries []TimeBucket
+// Splits each file touched in the bucket evenly among the authors who touched
+// it, setting each tally's file share. This has to happen at the final
+// resolution, since the shares don't survive merging buckets. Tallies are
+// copied, not modified.
+func (b TimeBucket) shareFiles() TimeBucket {
+	files := map[string][]string{} // Tally key -> canonical paths
+	authors := map[string]int{}    // Path -> number of authors
+	for key, tally := range b.tallies {
+		for path := range tally.canonicalFiles() {
+			files[key] = append(files[key], path)
+			authors[path] += 1
+		}
+	}
+
+	shared := make(map[string]Tally, len(b.tallies))
+	for key, tally := range b.tallies {
+		tally.fileShare = 0
+		for _, path := range files[key] {
+			tally.fileShare += 1 / float64(authors[path])
+		}
+		shared[key] = tally
+	}
+
+	b.tallies = shared
+	return b
+}
+
+// Key of the tally that Prune() folds the remaining authors into.
+const OthersKey = ".git-who-others"
+
+// Keeps the tallies of the top n authors in the bucket, ordered by mode, and
+// folds everyone else's tallies into a single tally under OthersKey. This
+// keeps big buckets small, e.g. for JSON output.
+//
+// The bucket should have been ranked first so that TotalTally still counts
+// everyone. Re-ranking a pruned bucket can make "Others" the winner.
+func (b TimeBucket) Prune(n int, mode TallyMode) TimeBucket {
+	if len(b.tallies) <= n {
+		return b
+	}
+
+	keys := slices.SortedFunc(maps.Keys(b.tallies), func(x, y string) int {
+		// Descending order
+		if c := b.tallies[y].Final().Compare(b.tallies[x].Final(), mode); c != 0 {
+			return c
+		}
+		return strings.Compare(x, y)
+	})
+
+	pruned := make(map[string]Tally, n+1)
+	others := newOthersTally()
+	for i, key := range keys {
+		if i < n {
+			pruned[key] = b.tallies[key]
+		} else {
+			others = others.Combine(b.tallies[key])
+		}
+	}
+	others.email = "" // Combine() took the first author's email
+	pruned[OthersKey] = others
+
+	b.tallies = pruned
+	return b
+}
+
+// Folds the tallies of the authors contributing less than the given share of
+// the bucket's TotalValue(), e.g. 0.05 for five percent, into the tally under
+// OthersKey, as with Prune(). Values are compared by magnitude, so that net
+// lines removed count as much as net lines added.
+//
+// The bucket should have been ranked first using the same mode, since the
+// share is of TotalTally. The winner isn't picked again.
+func (b TimeBucket) FoldBelow(share float64, mode TallyMode) TimeBucket {
+	threshold := share * math.Abs(float64(b.TotalValue(mode)))
+
+	kept := make(map[string]Tally, len(b.tallies))
+	others, folded := b.tallies[OthersKey]
+	if folded {
+		others = others.clone() // Don't union into the original's sets
+	} else {
+		others = newOthersTally()
+	}
+	for key, tally := range b.tallies {
+		if key == OthersKey {
+			continue
+		}
+
+		value := math.Abs(float64(tallyValue(tally.Final(), mode)))
+		if value < threshold {
+			others = others.Combine(tally)
+			folded = true
+		} else {
+			kept[key] = tally
+		}
+	}
+	if folded {
+		others.email = "" // Combine() took the first author's email
+		kept[OthersKey] = others
+	}
+
+	b.tallies = kept
+	return b
+}
+
+// Returns an empty tally to fold other authors' tallies into.
+func newOthersTally() Tally {
+	return Tally{
+		name:            "Others",
+		commitset:       map[string]bool{},
+		fileset:         map[string]bool{},
+		firstCommitTime: time.Unix(1<<62, 0),
+	}
+}
+
+type TimeSeries []TimeBucket
+
+// Merges two series, bucket by bucket. Buckets are matched up by start time,
+// so the series should have the same resolution, but they can span different
+// periods. The result covers every bucket in either series; call Trim() on it
+// to drop empty buckets at the ends.
+func (a TimeSeries) Combine(b TimeSeries) (TimeSeries, error) {
+	buckets := map[int64]TimeBucket{}
+	for _, bucket := range a {
+		buckets[bucket.Time.Unix()] = bucket
+	}
+	for _, bucket := range b {
+		existing, ok := buckets[bucket.Time.Unix()]
+		if ok {
+			combined, err := existing.Combine(bucket)
+			if err != nil {
+				return nil, fmt.Errorf("error combining time series: %w", err)
+			}
+			buckets[bucket.Time.Unix()] = combined
+		} else {
+			buckets[bucket.Time.Unix()] = bucket
+		}
+	}
+
+	sortedKeys := slices.Sorted(maps.Keys(buckets))
+
+	outBuckets := TimeSeries{}
+	for _, key := range sortedKeys {
+		outBuckets = append(outBuckets, buckets[key])
+	}
+
+	return outBuckets, nil
+}
+
+// Returns the series without any leading or trailing empty buckets. Empty
+// buckets between non-empty ones are kept so that the spacing of the series
+// stays accurate.
+func (s TimeSeries) Trim() TimeSeries {
+	first := slices.IndexFunc(s, func(b TimeBucket) bool { return !b.isEmpty() })
+	if first < 0 {
+		return TimeSeries{}
+	}
+
+	last := len(s) - 1
+	for s[last].isEmpty() {
+		last -= 1
+	}
+
+	return s[first : last+1]
+}
+
+// Returns the series with an empty bucket inserted wherever the resolution
+// expects a bucket between the first and last bucket but there is none.
+//
+// Returns an error if a bucket in the series doesn't line up with the
+// resolution. Use Rebucket() to change the resolution of a series.
+func (s TimeSeries) FillGaps(res Resolution) (TimeSeries, error) {
+	if len(s) == 0 {
+		return s, nil
+	}
+
+	buckets := map[int64]TimeBucket{}
+	first := s[0].Time
+	last := s[0].Time
+	for _, bucket := range s {
+		if !res.apply(bucket.Time).Equal(bucket.Time) {
+			return nil, fmt.Errorf(
+				"bucket \"%s\" does not match resolution",
+				bucket.Name,
+			)
+		}
+
+		buckets[bucket.Time.Unix()] = bucket
+		first = timeutils.Min(first, bucket.Time)
+		last = timeutils.Max(last, bucket.Time)
+	}
+
+	filled := TimeSeries{}
+	for t := first; !t.After(last); t = res.next(t) {
+		bucket, ok := buckets[t.Unix()]
+		if !ok {
+			bucket = res.bucketFor(t)
+		}
+
+		filled = append(filled, bucket)
+	}
+
+	return filled, nil
+}
+
+// Re-aggregates the series into buckets of the given resolution, combining the
+// per-author tallies of buckets that land in the same new bucket. The new
+// series spans the buckets in this one, which don't need to be in order.
+//
+// The resolution should be coarser than the series' current one; a finer
+// resolution can't split up the tallies. The new buckets need to be ranked.
+func (s TimeSeries) Rebucket(res Resolution) TimeSeries {
+	if len(s) == 0 {
+		return s
+	}
+
+	return Rebucket(s, res, s[0].Time, s[0].Time)
+}
+
+// Returns each author's tally combined across every bucket in the series.
+func (s TimeSeries) authorTallies() map[string]Tally {
+	tallies := map[string]Tally{}
+	for _, bucket := range s {
+		for key, tally := range bucket.tallies {
+			existing, ok := tallies[key]
+			if ok {
+				tallies[key] = existing.Combine(tally)
+			} else {
+				tallies[key] = tally.clone()
+			}
+		}
+	}
+
+	return tallies
+}
+
+// Returns one tally for all authors across every bucket in the series. Files
+// touched in more than one bucket are only counted once. The tally has no
+// author name or email.
+func (s TimeSeries) Totals() FinalTally {
+	tallies := s.authorTallies()
+	if len(tallies) == 0 {
+		return FinalTally{}
+	}
+
+	runningTally := Tally{
+		commitset:       map[string]bool{},
+		fileset:         map[string]bool{},
+		firstCommitTime: time.Unix(1<<62, 0),
+	}
+	for _, tally := range tallies {
+		runningTally = runningTally.Combine(tally)
+	}
+
+	totals := runningTally.Final()
+	totals.AuthorName = ""
+	totals.AuthorEmail = ""
+	return totals
+}
+
+// Removes the authors with fewer than minCommits commits across the whole
+// series from every bucket, e.g. to hide drive-by contributors. If fold is
+// true, their tallies are folded into the tally under OthersKey, as with
+// Prune(), instead of being dropped.
+//
+// The buckets should have been ranked first so that TotalTally still counts
+// everyone. Each bucket's winner is picked again from the remaining authors,
+// leaving out "Others", and is a zero tally if no one remains.
+func (s TimeSeries) DropMinorAuthors(
+	minCommits int,
+	fold bool,
+	mode TallyMode,
+) TimeSeries {
+	commits := map[string]int{}
+	for key, tally := range s.authorTallies() {
+		commits[key] = tally.Final().Commits
+	}
+
+	out := make(TimeSeries, len(s))
+	for i, b := range s {
+		kept := map[string]Tally{}
+		for key, tally := range b.tallies {
+			if key != OthersKey && commits[key] >= minCommits {
+				kept[key] = tally
+			}
+		}
+
+		b.Tally = FinalTally{}
+		b.RunnerUp = FinalTally{}
+		if ranked := Rank(kept, mode); len(ranked) > 0 {
+			b.Tally = placing(ranked[0], mode)
+			if len(ranked) > 1 {
+				b.RunnerUp = placing(ranked[1], mode)
+			}
+		}
+
+		others, folded := b.tallies[OthersKey]
+		if folded {
+			others = others.clone() // Don't union into the original's sets
+		} else {
+			others = newOthersTally()
+		}
+		if fold {
+			for key, tally := range b.tallies {
+				if _, ok := kept[key]; !ok && key != OthersKey {
+					others = others.Combine(tally)
+					folded = true
+				}
+			}
+			others.email = "" // Combine() took the first author's email
+		}
+		if folded {
+			kept[OthersKey] = others
+		}
+
+		b.tallies = kept
+		out[i] = b
+	}
+
+	return out
+}
+
+// Returns the tally of the top author, ordered by mode, across every bucket in
+// the series. Returns a zero tally if the series is empty.
+func (s TimeSeries) Winner(mode TallyMode) FinalTally {
+	tallies := s.authorTallies()
+	if len(tallies) == 0 {
+		return FinalTally{}
+	}
+
+	return Rank(tallies, mode)[0]
+}
+
+// The span of a time series in which an author was active. Both ends are
+// bucket times, so this depends on the resolution of the series.
+type Tenure struct {
+	AuthorName    string
+	AuthorEmail   string
+	First         time.Time // Start of the first bucket the author appears in
+	Last          time.Time // Start of the last bucket the author appears in
+	ActiveBuckets int       // Number of buckets the author appears in
+}
+
+// Returns the first and last bucket each author appears in, along with how
+// many buckets they appear in, e.g. to tell long-term maintainers from authors
+// who came and went. Ordered by first bucket and then by author. The tally
+// under OthersKey is left out.
+func (s TimeSeries) Tenures() []Tenure {
+	tenures := map[string]Tenure{}
+	for _, bucket := range s {
+		for key, tally := range bucket.tallies {
+			if key == OthersKey {
+				continue
+			}
+
+			tenure, ok := tenures[key]
+			if !ok {
+				tenure = Tenure{
+					AuthorName:  tally.name,
+					AuthorEmail: tally.email,
+					First:       bucket.Time,
+					Last:        bucket.Time,
+				}
+			}
+
+			tenure.First = timeutils.Min(tenure.First, bucket.Time)
+			tenure.Last = timeutils.Max(tenure.Last, bucket.Time)
+			tenure.ActiveBuckets += 1
+			tenures[key] = tenure
+		}
+	}
+
+	return slices.SortedFunc(maps.Values(tenures), func(a, b Tenure) int {
+		if c := a.First.Compare(b.First); c != 0 {
+			return c
+		}
+		if a.AuthorEmail != b.AuthorEmail {
+			return strings.Compare(a.AuthorEmail, b.AuthorEmail)
+		}
+		return strings.Compare(a.AuthorName, b.AuthorName)
+	})
+}
+
+type identity struct {
+	name  string
+	email string
+}
+
+// Returns the tally with its name and email replaced by the matching
+// pseudonym, if there is one.
+func anonymizeTally(t FinalTally, pseudonyms map[identity]string) FinalTally {
+	pseudonym, ok := pseudonyms[identity{t.AuthorName, t.AuthorEmail}]
+	if !ok {
+		return t
+	}
+
+	t.AuthorName, t.AuthorEmail = pseudonymIdentity(pseudonym, t.AuthorEmail)
+	return t
+}
+
+// Emails are only given a pseudonym if there was one to begin with, e.g. not
+// for teams.
+func pseudonymIdentity(pseudonym string, email string) (string, string) {
+	if email == "" {
+		return pseudonym, ""
+	}
+
+	local := strings.ToLower(strings.ReplaceAll(pseudonym, " ", ""))
+	return pseudonym, local + "@anonymous.invalid"
+}
+
+// Returns a copy of the series with every author's name, email and key
+// replaced by a pseudonym like "Author 3", e.g. for sharing a report outside
+// the company. Each author has the same pseudonym in every bucket. Authors are
+// numbered in order of their first bucket, then by key. The tally under
+// OthersKey keeps its name.
+//
+// Winners aren't picked again, so the ranking stays as it was. Rank the
+// buckets first; ranking the copies may break ties differently.
+func (s TimeSeries) Anonymize() TimeSeries {
+	pseudonyms := map[string]string{} // Key -> pseudonym
+	chronological := slices.SortedStableFunc(
+		slices.Values(s),
+		func(a, b TimeBucket) int { return a.Time.Compare(b.Time) },
+	)
+	for _, bucket := range chronological {
+		for _, key := range slices.Sorted(maps.Keys(bucket.tallies)) {
+			if _, ok := pseudonyms[key]; !ok && key != OthersKey {
+				pseudonyms[key] = fmt.Sprintf("Author %d", len(pseudonyms)+1)
+			}
+		}
+	}
+
+	out := make(TimeSeries, len(s))
+	for i, bucket := range s {
+		byIdentity := map[identity]string{}
+		tallies := make(map[string]Tally, len(bucket.tallies))
+		for key, tally := range bucket.tallies {
+			pseudonym, ok := pseudonyms[key]
+			if !ok {
+				tallies[key] = tally
+				continue
+			}
+
+			byIdentity[identity{tally.name, tally.email}] = pseudonym
+			tally.name, tally.email = pseudonymIdentity(pseudonym, tally.email)
+			tallies[pseudonym] = tally
+		}
+
+		bucket.tallies = tallies
+		bucket.Tally = anonymizeTally(bucket.Tally, byIdentity)
+		bucket.RunnerUp = anonymizeTally(bucket.RunnerUp, byIdentity)
+		bucket.TotalTally = anonymizeTally(bucket.TotalTally, byIdentity)
+		out[i] = bucket
+	}
+
+	return out
+}
+
+// How one bucket differs between two time series. See TimeSeries.Diff().
+type BucketDiff struct {
+	Name          string
+	Time          time.Time
+	InSeries      bool       // Whether the series has the bucket
+	InOther       bool       // Whether the other series has the bucket
+	Winner        FinalTally // Zero if the series doesn't have the bucket
+	OtherWinner   FinalTally
+	Delta         int64 // Other series' value minus the series' value
+	WinnerChanged bool
+}
+
+// Compares the series bucket by bucket with another series of the same
+// resolution, e.g. to see how a branch differs from main. Buckets are matched
+// up by start time and the diffs are in chronological order.
+//
+// A bucket in only one of the series counts as having a value of zero and no
+// winner there, and has InSeries or InOther set to false. The winner changed
+// if it's a different author, or if only one side has a winner. Both series
+// should have been ranked using the same mode.
+func (s TimeSeries) Diff(other TimeSeries, mode TallyMode) []BucketDiff {
+	diffs := map[int64]BucketDiff{}
+	for _, bucket := range s {
+		diffs[bucket.Time.Unix()] = BucketDiff{
+			Name:     bucket.Name,
+			Time:     bucket.Time,
+			InSeries: true,
+			Winner:   bucket.Tally,
+			Delta:    -bucket.Value(mode),
+		}
+	}
+	for _, bucket := range other {
+		diff, ok := diffs[bucket.Time.Unix()]
+		if !ok {
+			diff = BucketDiff{Name: bucket.Name, Time: bucket.Time}
+		}
+
+		diff.InOther = true
+		diff.OtherWinner = bucket.Tally
+		diff.Delta += bucket.Value(mode)
+		diffs[bucket.Time.Unix()] = diff
+	}
+
+	out := make([]BucketDiff, 0, len(diffs))
+	for _, key := range slices.Sorted(maps.Keys(diffs)) {
+		diff := diffs[key]
+		diff.WinnerChanged = compareIdentity(diff.Winner, diff.OtherWinner) != 0
+		out = append(out, diff)
+	}
+
+	return out
+}
+
+// Returns a copy of the buckets sorted by Value() in descending order, e.g. to
+// find the busiest months. Buckets with equal values stay in chronological
+// order. The buckets should have been ranked.
+func (s TimeSeries) SortedByValue(mode TallyMode) []TimeBucket {
+	return slices.SortedStableFunc(
+		slices.Values(s),
+		func(a, b TimeBucket) int {
+			if c := cmp.Compare(b.Value(mode), a.Value(mode)); c != 0 {
+				return c
+			}
+			return a.Time.Compare(b.Time)
+		},
+	)
+}
+
+// Returns the bucket with the greatest TotalValue(), e.g. the busiest month.
+// Ties go to the earliest bucket. Returns false if every bucket has a total
+// value of zero. The buckets should have been ranked.
+func (s TimeSeries) Peak(mode TallyMode) (TimeBucket, bool) {
+	return s.extreme(mode, 1)
+}
+
+// Returns the bucket with the smallest non-zero TotalValue(), e.g. the
+// quietest month that saw any work. Ties go to the earliest bucket. Returns
+// false if every bucket has a total value of zero. The buckets should have
+// been ranked.
+func (s TimeSeries) Trough(mode TallyMode) (TimeBucket, bool) {
+	return s.extreme(mode, -1)
+}
+
+// Finds the bucket whose non-zero total value compares furthest in the
+// direction of sign.
+func (s TimeSeries) extreme(mode TallyMode, sign int) (TimeBucket, bool) {
+	var found TimeBucket
+	ok := false
+	for _, bucket := range s {
+		value := bucket.TotalValue(mode)
+		if bucket.isEmpty() || value == 0 {
+			continue
+		}
+
+		c := sign * cmp.Compare(value, found.TotalValue(mode))
+		if !ok || c > 0 || (c == 0 && bucket.Time.Before(found.Time)) {
+			found = bucket
+			ok = true
+		}
+	}
+
+	return found, ok
+}
+
+// Returns the trailing average of Value() over the given number of buckets, for
+// each bucket in the series. Buckets near the start of the series average over
+// however many buckets precede them. The buckets should have been ranked.
+func (s TimeSeries) MovingAverage(window int, mode TallyMode) []float64 {
+	window = max(window, 1)
+
+	averages := make([]float64, len(s))
+	var sum int64
+	for i, bucket := range s {
+		sum += bucket.Value(mode)
+		if i >= window {
+			sum -= s[i-window].Value(mode)
+		}
+
+		averages[i] = float64(sum) / float64(min(i+1, window))
+	}
+
+	return averages
+}
+
+// Resolution for a time series.
+//
+// apply - Truncate time to its time bucket
+// label - Format the date to a label for the bucket
+// next - Get next time in series, given a time
+// end - Get the (exclusive) end of the time bucket; defaults to next
+type Resolution struct {
+	apply func(time.Time) time.Time
+	label func(time.Time) string
+	next  func(time.Time) time.Time
+	end   func(time.Time) time.Time
+}
+
+// Returns a new, empty bucket containing the given time.
+func (r Resolution) bucketFor(t time.Time) TimeBucket {
+	bucket := newBucket(r.label(t), r.apply(t))
+	if r.end != nil {
+		bucket.EndTime = r.end(t)
+	} else {
+		bucket.EndTime = r.next(t)
+	}
+
+	return bucket
+}
+
+func dailyIn(loc *time.Location) Resolution {
+	apply := func(t time.Time) time.Time {
+		year, month, day := t.In(loc).Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, day := t.Date()
+			return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			return apply(t).Format(time.DateOnly)
+		},
+	}
+}
+
+// Daily buckets for Monday through Friday only. Commits made on the weekend
+// land in the bucket for the following Monday or, if toFriday is true, the
+// preceding Friday.
+func businessDailyIn(loc *time.Location, toFriday bool) Resolution {
+	daily := dailyIn(loc)
+	apply := func(t time.Time) time.Time {
+		t = daily.apply(t)
+		year, month, day := t.Date()
+		switch t.Weekday() {
+		case time.Saturday:
+			if toFriday {
+				return time.Date(year, month, day-1, 0, 0, 0, 0, loc)
+			}
+			return time.Date(year, month, day+2, 0, 0, 0, 0, loc)
+		case time.Sunday:
+			if toFriday {
+				return time.Date(year, month, day-2, 0, 0, 0, 0, loc)
+			}
+			return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		default:
+			return t
+		}
+	}
+	next := func(t time.Time) time.Time {
+		t = apply(t)
+		year, month, day := t.Date()
+		if t.Weekday() == time.Friday {
+			return time.Date(year, month, day+3, 0, 0, 0, 0, loc)
+		}
+		return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	}
+
+	resolution := Resolution{
+		apply: apply,
+		next:  next,
+		label: func(t time.Time) string {
+			return apply(t).Format(time.DateOnly)
+		},
+	}
+	if !toFriday {
+		// The weekend belongs to Monday, so Friday ends at midnight
+		resolution.end = func(t time.Time) time.Time {
+			return daily.next(apply(t))
+		}
+	}
+
+	return resolution
+}
+
+// First day of the week for weekly buckets.
+const weekStart = time.Monday
+
+func weeklyIn(loc *time.Location) Resolution {
+	apply := func(t time.Time) time.Time {
+		t = t.In(loc)
+		year, month, day := t.Date()
+		offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, day := t.Date()
+			return time.Date(year, month, day+7, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			year, week := apply(t).ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		},
+	}
+}
+
+func monthlyIn(loc *time.Location) Resolution {
+	apply := func(t time.Time) time.Time {
+		year, month, _ := t.In(loc).Date()
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, _ := t.Date()
+			return time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			return apply(t).Format("Jan 2006")
+		},
+	}
+}
+
+// Quarterly buckets, where the first quarter starts in the given month.
+func quarterlyIn(loc *time.Location, fiscalStart time.Month) Resolution {
+	apply := func(t time.Time) time.Time {
+		year, month, _ := t.In(loc).Date()
+		monthsIn := (int(month) - int(fiscalStart) + 12) % 12
+		return time.Date(year, month-time.Month(monthsIn%3), 1, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, _ := t.Date()
+			return time.Date(year, month+3, 1, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			t = apply(t)
+			monthsIn := (int(t.Month()) - int(fiscalStart) + 12) % 12
+			quarter := monthsIn/3 + 1
+			if fiscalStart == time.January {
+				return fmt.Sprintf("%d Q%d", t.Year(), quarter)
+			}
+
+			return fmt.Sprintf("%s Q%d", fiscalYearLabel(t, fiscalStart), quarter)
+		},
+	}
+}
+
+// Yearly buckets, where the year starts in the given month.
+func yearlyIn(loc *time.Location, fiscalStart time.Month) Resolution {
+	apply := func(t time.Time) time.Time {
+		year, month, _ := t.In(loc).Date()
+		if month < fiscalStart {
+			year -= 1
+		}
+		return time.Date(year, fiscalStart, 1, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, _ := t.Date()
+			return time.Date(year+1, month, 1, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			t = apply(t)
+			if fiscalStart == time.January {
+				return t.Format("2006")
+			}
+
+			return fiscalYearLabel(t, fiscalStart)
+		},
+	}
+}
+
+// Fiscal years are named after the calendar year they end in, so e.g. FY2024
+// runs from July 2023 to June 2024.
+func fiscalYearLabel(t time.Time, fiscalStart time.Month) string {
+	year, month, _ := t.Date()
+	if month >= fiscalStart {
+		year += 1
+	}
+
+	return fmt.Sprintf("FY%d", year)
+}
+
+// Buckets of a fixed number of days, one of which starts on the anchor date.
+func intervalIn(loc *time.Location, days int, anchor time.Time) Resolution {
+	if days < 1 {
+		panic("interval resolution needs an interval of at least one day")
+	}
+
+	anchor = dailyIn(loc).apply(anchor)
+
+	apply := func(t time.Time) time.Time {
+		// Count calendar days in UTC so that DST changes don't matter
+		y1, m1, d1 := anchor.Date()
+		y2, m2, d2 := t.In(loc).Date()
+		from := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+		elapsed := int(to.Sub(from).Hours() / 24)
+
+		n := elapsed / days
+		if elapsed%days < 0 {
+			n -= 1 // Round towards negative infinity before the anchor
+		}
+
+		return time.Date(y1, m1, d1+n*days, 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			t = apply(t)
+			year, month, day := t.Date()
+			return time.Date(year, month, day+days, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			t = apply(t)
+			year, month, day := t.Date()
+			last := time.Date(year, month, day+days-1, 0, 0, 0, 0, loc)
+			return fmt.Sprintf(
+				"%s – %s",
+				t.Format(time.DateOnly),
+				last.Format(time.DateOnly),
+			)
+		},
+	}
+}
+
+// Edges in days of the age bands used by relativeIn(). Past the last edge, each
+// band is a year long.
+var ageBandEdges = []int{0, 30, 90, 180, 365}
+
+// Returns the bounds in days of the age band containing the given age. The
+// lower bound is inclusive and the upper bound exclusive.
+func ageBand(age int) (int, int) {
+	if age < 0 {
+		age = 0 // Newer than the reference; count it as brand new
+	}
+
+	for i, edge := range ageBandEdges[1:] {
+		if age < edge {
+			return ageBandEdges[i], edge
+		}
+	}
+
+	years := age / 365
+	return years * 365, (years + 1) * 365
+}
+
+// Buckets covering bands of age, in calendar days back from the reference
+// time. Each bucket's time is the oldest day in its band.
+func relativeIn(loc *time.Location, reference time.Time) Resolution {
+	reference = dailyIn(loc).apply(reference)
+	y1, m1, d1 := reference.Date()
+
+	age := func(t time.Time) int {
+		// Count calendar days in UTC so that DST changes don't matter
+		y2, m2, d2 := t.In(loc).Date()
+		from := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+		to := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+		return int(to.Sub(from).Hours() / 24)
+	}
+	apply := func(t time.Time) time.Time {
+		_, hi := ageBand(age(t))
+		return time.Date(y1, m1, d1-(hi-1), 0, 0, 0, 0, loc)
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			lo, _ := ageBand(age(t))
+			if lo == 0 {
+				// Newest band; nothing comes after it
+				return time.Unix(1<<62, 0)
+			}
+			return time.Date(y1, m1, d1-(lo-1), 0, 0, 0, 0, loc)
+		},
+		end: func(t time.Time) time.Time {
+			lo, _ := ageBand(age(t))
+			return time.Date(y1, m1, d1-lo+1, 0, 0, 0, 0, loc)
+		},
+		label: func(t time.Time) string {
+			lo, hi := ageBand(age(t))
+			if lo < 365 {
+				return fmt.Sprintf("%d–%d days ago", lo, hi)
+			}
+			return fmt.Sprintf("%d–%d years ago", lo/365, hi/365)
+		},
+	}
+}
+
+// Buckets covering the ranges between consecutive releases. Each bucket's time
+// is the time of the release it starts with. Commits before the first release
+// land in a bucket starting at the zero time and commits after the last
+// release in a bucket that never ends.
+func releasesIn(releases []Release) Resolution {
+	releases = slices.SortedStableFunc(
+		slices.Values(releases),
+		func(a, b Release) int { return a.Time.Compare(b.Time) },
+	)
+
+	// Index of the release starting the range containing t, or -1 if t comes
+	// before the first release
+	index := func(t time.Time) int {
+		return sort.Search(len(releases), func(i int) bool {
+			return releases[i].Time.After(t)
+		}) - 1
+	}
+	apply := func(t time.Time) time.Time {
+		i := index(t)
+		if i < 0 {
+			return time.Time{}
+		}
+		return releases[i].Time
+	}
+	return Resolution{
+		apply: apply,
+		next: func(t time.Time) time.Time {
+			i := index(t)
+			if i+1 >= len(releases) {
+				// Latest range; nothing comes after it
+				return time.Unix(1<<62, 0)
+			}
+			return releases[i+1].Time
+		},
+		label: func(t time.Time) string {
+			i := index(t)
+			var from, to string
+			if i >= 0 {
+				from = releases[i].Name
+			}
+			if i+1 < len(releases) {
+				to = releases[i+1].Name
+			}
+			return from + ".." + to
+		},
+	}
+}
+
+// Share of commits at either end of a timeline that are left out when picking
+// its resolution, so that a few outlying commits don't stretch the span.
+const outlierShare = 0.05
+
+// Returns the span of the chronological buckets that holds all the commits but
+// the outliers at either end, for picking a resolution. Returns false if the
+// buckets hold no commits.
+func activeSpan(buckets []TimeBucket) (time.Time, time.Time, bool) {
+	total := 0
+	for _, bucket := range buckets {
+		total += bucket.CommitCount()
+	}
+	if total == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	skip := int(float64(total) * outlierShare) // At each end
+
+	var start, end time.Time
+	started := false
+	seen := 0
+	for _, bucket := range buckets {
+		count := bucket.CommitCount()
+		if count == 0 {
+			continue
+		}
+
+		seen += count
+		if !started && seen > skip {
+			start = bucket.Time
+			started = true
+		}
+		if seen >= total-skip {
+			end = bucket.Time
+			break
+		}
+	}
+
+	return start, end, true
+}
+
+// Picks a resolution mode based on the duration of the timeline.
+func autoResolutionMode(start time.Time, end time.Time) ResolutionMode {
+	duration := end.Sub(start)
+	day := time.Hour * 24
+	year := day * 365
+
+	if duration > year*5 {
+		return YearlyResolution
+	} else if duration > year {
+		return QuarterlyResolution
+	} else if duration > day*60 {
+		return WeeklyResolution
+	} else {
+		return DailyResolution
+	}
+}
+
+func CalcResolution(
+	start time.Time,
+	end time.Time,
+	loc *time.Location,
+) Resolution {
+	return ResolutionFor(TallyOpts{Location: loc}, start, end)
+}
+
+// Returns the resolution mode configured in the opts, or the one picked based
+// on the duration of the timeline when the mode is AutoResolution.
+func ResolutionModeFor(
+	opts TallyOpts,
+	start time.Time,
+	end time.Time,
+) ResolutionMode {
+	if opts.Resolution == AutoResolution {
+		return autoResolutionMode(start, end)
+	}
+
+	return opts.Resolution
+}
+
+// Returns the resolution configured in the opts, picking one based on the
+// duration of the timeline when the mode is AutoResolution. Buckets are
+// labeled using the opts' LabelFormats, if any.
+func ResolutionFor(opts TallyOpts, start time.Time, end time.Time) Resolution {
+	mode := ResolutionModeFor(opts, start, end)
+	resolution := defaultResolutionFor(mode, opts, start, end)
+
+	layout, ok := opts.LabelFormats[mode]
+	if !ok || layout == "" ||
+		mode == RelativeResolution ||
+		mode == ReleaseResolution {
+		return resolution
+	}
+
+	apply := resolution.apply
+	next := resolution.next
+	resolution.label = func(t time.Time) string {
+		t = apply(t)
+		if mode != IntervalResolution {
+			return t.Format(layout)
+		}
+
+		year, month, day := next(t).Date()
+		last := time.Date(year, month, day-1, 0, 0, 0, 0, t.Location())
+		return fmt.Sprintf("%s – %s", t.Format(layout), last.Format(layout))
+	}
+
+	return resolution
+}
+
+// Returns the resolution for the mode with the default bucket labels.
+func defaultResolutionFor(
+	mode ResolutionMode,
+	opts TallyOpts,
+	start time.Time,
+	end time.Time,
+) Resolution {
+	loc := opts.location()
+
+	switch mode {
+	case DailyResolution:
+		return dailyIn(loc)
+	case BusinessDayResolution:
+		return businessDailyIn(loc, opts.WeekendToFriday)
+	case WeeklyResolution:
+		return weeklyIn(loc)
+	case MonthlyResolution:
+		return monthlyIn(loc)
+	case QuarterlyResolution:
+		return quarterlyIn(loc, opts.fiscalYearStart())
+	case YearlyResolution:
+		return yearlyIn(loc, opts.fiscalYearStart())
+	case IntervalResolution:
+		anchor := opts.Anchor
+		if anchor.IsZero() {
+			anchor = start
+		}
+		return intervalIn(loc, opts.Interval, anchor)
+	case RelativeResolution:
+		reference := opts.Reference
+		if reference.IsZero() {
+			reference = end
+		}
+		return relativeIn(loc, reference)
+	case ReleaseResolution:
+		return releasesIn(opts.Releases)
+	default:
+		panic("unrecognized resolution mode in switch")
+	}
+}
+
+// Returns the resolution that commits are first tallied by date into, before
+// ToTimeline() rebuckets the daily buckets. Release ranges don't start at
+// midnight, so commits are bucketed by release straight away instead.
+func (opts TallyOpts) dateResolution() Resolution {
+	if opts.Resolution == ReleaseResolution {
+		return releasesIn(opts.Releases)
+	}
+
+	return dailyIn(opts.location())
+}
+
+// Adds the commit to the per-author tallies for a time bucket.
+func tallyCommit(tallies map[string]Tally, commit git.Commit, opts TallyOpts) {
+	if commit.IsMerge && !opts.CountMerges {
+		return
+	}
+
+	if opts.CoAuthors && opts.DiffKey == nil && len(commit.CoAuthors) > 0 {
+		coAuthors := commit.CoAuthors
+		commit.CoAuthors = nil
+		tallyCommit(tallies, commit, opts)
+
+		credited := map[string]bool{strings.ToLower(commit.AuthorEmail): true}
+		for _, coAuthor := range coAuthors {
+			email := strings.ToLower(coAuthor.Email)
+			if credited[email] {
+				continue
+			}
+			credited[email] = true
+
+			commit.AuthorName = coAuthor.Name
+			commit.AuthorEmail = coAuthor.Email
+			tallyCommit(tallies, commit, opts)
+		}
+		return
+	}
+
+	commit = normalizeAuthor(opts.Aliases.Apply(opts.Mailmap.Apply(commit)))
+
+	if !opts.matchesAuthorFilter(commit) || opts.isExcludedAuthor(commit) {
+		return
+	}
+
+	if !opts.matchesMessageFilter(commit) ||
+		!opts.matchesSignatureFilter(commit) {
+		return
+	}
+
+	diffs, ok := opts.filterDiffs(commit)
+	if !ok {
+		return
+	}
+
+	if opts.DiffKey != nil {
+		byKey := map[string][]git.FileDiff{}
+		for _, diff := range diffs {
+			key := opts.DiffKey(commit, diff)
+			byKey[key] = append(byKey[key], diff)
+		}
+
+		for key, keyDiffs := range byKey {
+			addToTally(tallies, key, key, "", commit, keyDiffs, opts)
+		}
+		return
+	}
+
+	if group, ok := opts.group(commit); ok {
+		addToTally(tallies, group, group, "", commit, diffs, opts)
+		return
+	}
+
+	key := opts.Key(commit)
+	addToTally(
+		tallies,
+		key,
+		commit.AuthorName,
+		commit.AuthorEmail,
+		commit,
+		diffs,
+		opts,
+	)
+}
+
+// Adds the commit, with the given file diffs, to the tally under key.
+func addToTally(
+	tallies map[string]Tally,
+	key string,
+	name string,
+	email string,
+	commit git.Commit,
+	diffs []git.FileDiff,
+	opts TallyOpts,
+) {
+	date := opts.commitDate(commit)
+
+	tally, ok := tallies[key]
+	if !ok {
+		tally.name = name
+		tally.email = email
+		tally.fileset = map[string]bool{}
+		tally.firstCommitTime = date
+		tally.keepFiles = opts.RetainFiles
+	}
+
+	tally.numTallied += 1
+	tally.firstCommitTime = timeutils.Min(date, tally.firstCommitTime)
+	tally.lastCommitTime = timeutils.Max(date, tally.lastCommitTime)
+
+	if opts.RetainCommits {
+		tally.commits = append(tally.commits, CommitRef{
+			Hash:    commit.Hash,
+			Subject: commit.Subject,
+			Time:    date,
+		})
+	}
+
+	if !commit.IsMerge || opts.MergeDiffs {
+		for _, diff := range diffs {
+			added, removed := opts.weighLines(commit, diff)
+			tally.added += added
+			tally.removed += removed
+			tally.churn += float64(added) +
+				opts.churnWeight()*float64(removed)
+			tally.fileset[diff.Path] = true
+
+			if diff.IsRename() {
+				if tally.renames == nil {
+					tally.renames = map[string]string{}
+				}
+				tally.renames[diff.OldPath] = diff.Path
+			}
+		}
+	}
+
+	tallies[key] = tally
+}
+
+// The earliest commit to touch each file path, for TallyOpts.FirstCommitOnly.
+type fileCreators map[string]fileCreator
+
+type fileCreator struct {
+	commit git.Commit // Without file diffs
+	diff   git.FileDiff
+}
+
+// Renaming a file doesn't create it. But we still want to know when the new
+// path first appeared, so that later commits don't get credited with it.
+func (c fileCreator) isCreation() bool {
+	return !c.diff.IsRename()
+}
+
+func (c fileCreators) add(commit git.Commit, opts TallyOpts) {
+	if commit.IsMerge && !opts.MergeDiffs {
+		return // Merges don't introduce files themselves
+	}
+
+	date := opts.commitDate(commit)
+	diffs := commit.FileDiffs
+	commit.FileDiffs = nil
+
+	for _, diff := range diffs {
+		// On ties, prefer the commit seen first, since we run git log with
+		// --reverse
+		existing, ok := c[diff.Path]
+		if !ok || date.Before(opts.commitDate(existing.commit)) {
+			c[diff.Path] = fileCreator{commit: commit, diff: diff}
+		}
+	}
+}
+
+// Returns the commits that created at least one file, each with only the file
+// diffs for the files it created.
+func (c fileCreators) commits() []git.Commit {
+	byHash := map[string]git.Commit{}
+	for _, creator := range c {
+		if !creator.isCreation() {
+			continue
+		}
+
+		commit, ok := byHash[creator.commit.Hash]
+		if !ok {
+			commit = creator.commit
+		}
+
+		commit.FileDiffs = append(commit.FileDiffs, creator.diff)
+		byHash[creator.commit.Hash] = commit
+	}
+
+	return slices.Collect(maps.Values(byHash))
+}
+
+// Commits buffered for TallyOpts.MergeDiffs. We can only tell which commits
+// landed on the mainline once we've seen them all.
+type landings struct {
+	commits []git.Commit
+	byHash  map[string]int // Hash -> index in commits
+}
+
+func newLandings() *landings {
+	return &landings{byHash: map[string]int{}}
+}
+
+func (l *landings) add(commit git.Commit) {
+	if commit.Hash != "" {
+		l.byHash[commit.Hash] = len(l.commits)
+	}
+	l.commits = append(l.commits, commit)
+}
+
+// Returns the commits on the first-parent chain of each tip, where a tip is a
+// commit that isn't the parent of any other commit we've seen. Commits keep
+// the order they were added in.
+func (l *landings) landed() []git.Commit {
+	isParent := map[string]bool{}
+	for _, commit := range l.commits {
+		for _, parent := range commit.Parents {
+			isParent[parent] = true
+		}
+	}
+
+	onMainline := make([]bool, len(l.commits))
+	for i, commit := range l.commits {
+		if commit.Hash != "" && isParent[commit.Hash] {
+			continue
+		}
+
+		// Walk back from the tip until we leave the commits we've seen or
+		// join a chain we've already walked
+		for !onMainline[i] {
+			onMainline[i] = true
+			if len(l.commits[i].Parents) == 0 {
+				break
+			}
 
-func (a TimeSeries) Combine(b TimeSeries) TimeSeries {
-	buckets := map[int64]TimeBucket{}
-	for _, bucket := range a {
-		buckets[bucket.Time.Unix()] = bucket
+			next, ok := l.byHash[l.commits[i].Parents[0]]
+			if !ok {
+				break
+			}
+			i = next
+		}
 	}
-	for _, bucket := range b {
-		existing, ok := buckets[bucket.Time.Unix()]
-		if ok {
-			buckets[bucket.Time.Unix()] = existing.Combine(bucket)
-		} else {
-			buckets[bucket.Time.Unix()] = bucket
+
+	landed := []git.Commit{}
+	for i, commit := range l.commits {
+		if onMainline[i] {
+			landed = append(landed, commit)
 		}
 	}
 
-	sortedKeys := slices.Sorted(maps.Keys(buckets))
+	return landed
+}
 
-	outBuckets := []TimeBucket{}
-	for _, key := range sortedKeys {
-		outBuckets = append(outBuckets, buckets[key])
+// Wraps an error from the commit iterator with the last commit read
+// successfully, so that the user knows where in the history to look.
+func iterationError(
+	err error,
+	lastGood *git.Commit,
+	resolution Resolution,
+	opts TallyOpts,
+) error {
+	if lastGood == nil {
+		return fmt.Errorf("error iterating commits: %w", err)
 	}
 
-	return outBuckets
+	return fmt.Errorf(
+		"error iterating commits after commit %s (bucket %s): %w",
+		lastGood.Name(),
+		resolution.label(opts.commitDate(*lastGood)),
+		err,
+	)
 }
 
-// Resolution for a time series.
-//
-// apply - Truncate time to its time bucket
-// label - Format the date to a label for the bucket
-// next - Get next time in series, given a time
-type Resolution struct {
-	apply func(time.Time) time.Time
-	label func(time.Time) string
-	next  func(time.Time) time.Time
-}
+// How many commits TallyCommitsByDateContext() reads between checks for
+// cancellation.
+const cancelCheckInterval = 100
 
-func applyDaily(t time.Time) time.Time {
-	year, month, day := t.Date()
-	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+// Returned (wrapped) along with the buckets tallied so far when a tally stops
+// partway through the commits, because the commit iterator failed or the
+// context was cancelled. The buckets only cover the commits read before then.
+var ErrIncomplete = errors.New("tally is incomplete")
+
+// Returned (wrapped) when a timeline would have more buckets than
+// TallyOpts.MaxBuckets allows.
+var ErrTooManyBuckets = errors.New("too many time buckets")
+
+// Default for TallyOpts.MaxBuckets. That's enough for daily buckets over more
+// than 250 years.
+const DefaultMaxBuckets = 100_000
+
+func tooManyBuckets(start time.Time, end time.Time, limit int) error {
+	return fmt.Errorf(
+		"%w: timeline from %s to %s needs more than %d buckets; "+
+			"check for bad dates",
+		ErrTooManyBuckets,
+		start.Format(time.DateOnly),
+		end.Format(time.DateOnly),
+		limit,
+	)
 }
 
-var daily = Resolution{
-	apply: applyDaily,
-	next: func(t time.Time) time.Time {
-		t = applyDaily(t)
-		year, month, day := t.Date()
-		return time.Date(year, month, day+1, 0, 0, 0, 0, time.Local)
-	},
-	label: func(t time.Time) string {
-		return applyDaily(t).Format(time.DateOnly)
-	},
+// Returns tallies grouped by calendar date, or by release range when the opts
+// use ReleaseResolution.
+//
+// Commits may arrive in any order. (git log does not strictly order commits by
+// author date.)
+//
+// If the commit iterator yields an error, the buckets tallied from the
+// commits before it are returned along with an error wrapping both
+// ErrIncomplete and the iterator's error. Check for ErrIncomplete before
+// using the buckets of a failed tally; other errors come with no buckets.
+func TallyCommitsByDate(
+	commits iter.Seq2[git.Commit, error],
+	opts TallyOpts,
+) ([]TimeBucket, error) {
+	return TallyCommitsByDateContext(context.Background(), commits, opts)
 }
 
-func CalcResolution(start time.Time, end time.Time) Resolution {
-	duration := end.Sub(start)
-	day := time.Hour * 24
-	year := day * 365
+// Like TallyCommitsByDate(), but for commits already held in memory, e.g. in
+// tests. The commits are sorted by date first (a stable sort on a copy) if
+// they aren't already, so that ties go to the commit that comes first, as with
+// git log --reverse.
+//
+// If the end time is non-zero and after the last commit, the buckets run up to
+// the end time, as with TallyCommitsTimeline(). The buckets are not rebucketed
+// into a coarser resolution.
+func TallyCommitsByDateSlice(
+	commits []git.Commit,
+	opts TallyOpts,
+	end time.Time,
+) ([]TimeBucket, error) {
+	byDate := func(a, b git.Commit) int {
+		return opts.commitDate(a).Compare(opts.commitDate(b))
+	}
+	if !slices.IsSortedFunc(commits, byDate) {
+		commits = slices.Clone(commits)
+		slices.SortStableFunc(commits, byDate)
+	}
 
-	if duration > year*5 {
-		// Yearly buckets
-		apply := func(t time.Time) time.Time {
-			year, _, _ := t.Date()
-			return time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
-		}
-		return Resolution{
-			apply: apply,
-			next: func(t time.Time) time.Time {
-				t = apply(t)
-				year, _, _ := t.Date()
-				return time.Date(year+1, 1, 1, 0, 0, 0, 0, time.Local)
-			},
-			label: func(t time.Time) string {
-				return apply(t).Format("2006")
-			},
-		}
-	} else if duration > day*60 {
-		// Monthly buckets
-		apply := func(t time.Time) time.Time {
-			year, month, _ := t.Date()
-			return time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
-		}
-		return Resolution{
-			apply: apply,
-			next: func(t time.Time) time.Time {
-				t = apply(t)
-				year, month, _ := t.Date()
-				return time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local)
-			},
-			label: func(t time.Time) string {
-				return apply(t).Format("Jan 2006")
-			},
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil || len(buckets) == 0 || end.IsZero() {
+		return buckets, err
+	}
+
+	resolution := opts.dateResolution()
+	t := resolution.next(buckets[len(buckets)-1].Time)
+	for !t.After(end) {
+		if len(buckets) >= opts.maxBuckets() {
+			return nil, tooManyBuckets(buckets[0].Time, end, opts.maxBuckets())
 		}
-	} else {
-		return daily
+
+		buckets = append(buckets, resolution.bucketFor(t))
+		t = resolution.next(t)
 	}
+
+	return buckets, nil
 }
 
-// Returns tallies grouped by calendar date.
-func TallyCommitsByDate(
+// Like TallyCommitsByDate(), but stops reading commits once the context is
+// cancelled. The buckets tallied so far are returned along with an error
+// wrapping both ErrIncomplete and the context's error.
+//
+// This doesn't stop whatever produces the commits; run git log with the same
+// context for that.
+func TallyCommitsByDateContext(
+	ctx context.Context,
 	commits iter.Seq2[git.Commit, error],
 	opts TallyOpts,
 ) (_ []TimeBucket, err error) {
@@ -198,8 +1848,14 @@ func TallyCommitsByDate(
 		}
 	}()
 
+	err = opts.Validate()
+	if err != nil {
+		return nil, err
+	}
 	if opts.Mode == LastModifiedMode || opts.Mode == FirstModifiedMode {
-		return nil, errors.New("mode not implemented")
+		return nil, errors.New(
+			"last-modified and first-modified modes don't work for timelines",
+		)
 	}
 
 	var (
@@ -207,16 +1863,55 @@ func TallyCommitsByDate(
 		maxTime time.Time
 	)
 
-	resolution := daily
+	opts = opts.withDecayReference()
+	resolution := opts.dateResolution()
 	buckets := map[int64]TimeBucket{} // Map of (unix) time to bucket
+	creators := fileCreators{}
+	landings := newLandings()
+	if opts.MergeDiffs {
+		opts.CountMerges = true
+	}
+
+	tallyCommitInto := func(commit git.Commit) {
+		bucketedCommitTime := resolution.apply(opts.commitDate(commit))
+		bucket, ok := buckets[bucketedCommitTime.Unix()]
+		if !ok {
+			bucket = resolution.bucketFor(bucketedCommitTime)
+		}
+
+		tallyCommit(bucket.tallies, commit, opts)
+		buckets[bucket.Time.Unix()] = bucket
+	}
+
+	var lastGood *git.Commit
+	var stopErr error // Why we stopped before the end of the commits
+	processed := 0
 
 	// Tally
 	for commit, err := range commits {
 		if err != nil {
-			return nil, fmt.Errorf("error iterating commits: %w", err)
+			stopErr = iterationError(err, lastGood, resolution, opts)
+			break
+		}
+
+		if processed%cancelCheckInterval == 0 {
+			if stopErr = ctx.Err(); stopErr != nil {
+				break
+			}
 		}
 
-		bucketedCommitTime := resolution.apply(commit.Date)
+		lastGood = &commit
+
+		processed += 1
+		if opts.Progress != nil && processed%ProgressInterval == 0 {
+			opts.Progress(processed)
+		}
+
+		if !opts.inWindow(commit) {
+			continue
+		}
+
+		bucketedCommitTime := resolution.apply(opts.commitDate(commit))
 		if bucketedCommitTime.Before(minTime) {
 			minTime = bucketedCommitTime
 		}
@@ -224,37 +1919,34 @@ func TallyCommitsByDate(
 			maxTime = bucketedCommitTime
 		}
 
-		bucket, ok := buckets[bucketedCommitTime.Unix()]
-		if !ok {
-			bucket = newBucket(
-				resolution.label(bucketedCommitTime),
-				resolution.apply(bucketedCommitTime),
-			)
+		if opts.MergeDiffs {
+			// Can't tell which commits landed until we've seen every commit
+			landings.add(commit)
+			continue
 		}
 
-		skipMerge := commit.IsMerge && !opts.CountMerges
-		if !skipMerge {
-			key := opts.Key(commit)
-
-			tally, ok := bucket.tallies[key]
-			if !ok {
-				tally.name = commit.AuthorName
-				tally.email = commit.AuthorEmail
-				tally.fileset = map[string]bool{}
-			}
+		if opts.FirstCommitOnly {
+			// Can't tally until we've seen every commit
+			creators.add(commit, opts)
+			continue
+		}
 
-			tally.numTallied += 1
+		tallyCommitInto(commit)
+	}
 
-			if !commit.IsMerge {
-				for _, diff := range commit.FileDiffs {
-					tally.added += diff.LinesAdded
-					tally.removed += diff.LinesRemoved
-					tally.fileset[diff.Path] = true
-				}
+	if opts.MergeDiffs {
+		for _, commit := range landings.landed() {
+			if opts.FirstCommitOnly {
+				creators.add(commit, opts)
+			} else {
+				tallyCommitInto(commit)
 			}
+		}
+	}
 
-			bucket.tallies[key] = tally
-			buckets[bucket.Time.Unix()] = bucket
+	if opts.FirstCommitOnly {
+		for _, commit := range creators.commits() {
+			tallyCommitInto(commit)
 		}
 	}
 
@@ -263,82 +1955,495 @@ func TallyCommitsByDate(
 	bucketSlice := []TimeBucket{}
 
 	for t.Before(maxTime) || t.Equal(maxTime) {
+		if len(bucketSlice) >= opts.maxBuckets() {
+			return nil, tooManyBuckets(minTime, maxTime, opts.maxBuckets())
+		}
+
 		bucket, ok := buckets[t.Unix()]
 		if !ok {
-			bucket = newBucket(resolution.label(t), resolution.apply(t))
+			bucket = resolution.bucketFor(t)
 		}
+		delete(buckets, t.Unix())
 
 		bucketSlice = append(bucketSlice, bucket)
 		t = resolution.next(t)
 	}
 
+	// Any buckets left over were never landed on while walking the series
+	// (e.g. because of a DST transition). Fold them into the nearest bucket
+	// rather than dropping them.
+	for _, bucket := range buckets {
+		i := nearestBucket(bucketSlice, bucket.Time)
+		logger().Warn(
+			"no bucket found for time; using nearest bucket",
+			"time", bucket.Time,
+			"nearest", bucketSlice[i].Time,
+		)
+
+		bucket.Time = bucketSlice[i].Time
+		bucket.Name = bucketSlice[i].Name
+		bucketSlice[i] = bucketSlice[i].merge(bucket)
+	}
+
+	if stopErr != nil {
+		return bucketSlice, fmt.Errorf("%w: %w", ErrIncomplete, stopErr)
+	}
+
 	return bucketSlice, nil
 }
 
-// Returns a list of "time buckets" with tallies for each date.
+// What TallyCommitsByClock() groups commits by.
+type ClockMode int
+
+const (
+	HourOfDay ClockMode = iota // 24 buckets, from 00:00 to 23:00
+	DayOfWeek                  // 7 buckets, from Monday to Sunday
+)
+
+// Returns tallies grouped by the hour of day or the day of week of each
+// commit, in the time zone given by the opts, across the whole history. This
+// shows when commits happen rather than how activity changes over time.
+//
+// Every bucket is returned, in order, even if empty. The buckets are
+// categorical: each has a name like "09:00" or "Mon" but a zero time.
+func TallyCommitsByClock(
+	commits iter.Seq2[git.Commit, error],
+	opts TallyOpts,
+	clock ClockMode,
+) (_ []TimeBucket, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error while tallying commits by clock: %w", err)
+		}
+	}()
+
+	err = opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Mode == LastModifiedMode || opts.Mode == FirstModifiedMode {
+		return nil, errors.New(
+			"last-modified and first-modified modes don't work for timelines",
+		)
+	}
+
+	opts = opts.withDecayReference()
+	loc := opts.location()
+
+	var buckets []TimeBucket
+	var index func(t time.Time) int
+	switch clock {
+	case HourOfDay:
+		for hour := range 24 {
+			buckets = append(
+				buckets,
+				newBucket(fmt.Sprintf("%02d:00", hour), time.Time{}),
+			)
+		}
+		index = func(t time.Time) int {
+			return t.In(loc).Hour()
+		}
+	case DayOfWeek:
+		for i := range 7 {
+			day := (weekStart + time.Weekday(i)) % 7
+			buckets = append(buckets, newBucket(day.String()[:3], time.Time{}))
+		}
+		index = func(t time.Time) int {
+			return (int(t.In(loc).Weekday()) - int(weekStart) + 7) % 7
+		}
+	default:
+		panic("unrecognized clock mode in switch")
+	}
+
+	// Only for labeling errors
+	resolution := Resolution{
+		label: func(t time.Time) string { return buckets[index(t)].Name },
+	}
+
+	tallyCommitInto := func(commit git.Commit) {
+		i := index(opts.commitDate(commit))
+		tallyCommit(buckets[i].tallies, commit, opts)
+	}
+
+	creators := fileCreators{}
+	landings := newLandings()
+	if opts.MergeDiffs {
+		opts.CountMerges = true
+	}
+	var lastGood *git.Commit
+
+	for commit, err := range commits {
+		if err != nil {
+			return nil, iterationError(err, lastGood, resolution, opts)
+		}
+
+		lastGood = &commit
+
+		if !opts.inWindow(commit) {
+			continue
+		}
+
+		if opts.MergeDiffs {
+			// Can't tell which commits landed until we've seen every commit
+			landings.add(commit)
+			continue
+		}
+
+		if opts.FirstCommitOnly {
+			// Can't tally until we've seen every commit
+			creators.add(commit, opts)
+			continue
+		}
+
+		tallyCommitInto(commit)
+	}
+
+	if opts.MergeDiffs {
+		for _, commit := range landings.landed() {
+			if opts.FirstCommitOnly {
+				creators.add(commit, opts)
+			} else {
+				tallyCommitInto(commit)
+			}
+		}
+	}
+
+	if opts.FirstCommitOnly {
+		for _, commit := range creators.commits() {
+			tallyCommitInto(commit)
+		}
+	}
+
+	return buckets, nil
+}
+
+// Returns an iterator over tallies grouped by calendar date.
+//
+// Unlike TallyCommitsByDate(), each bucket is ranked and yielded as soon as the
+// commit stream moves past it, so the caller does not have to wait for the
+// whole history to be read. This requires commits to arrive in chronological
+// order; an error is yielded if a commit is dated before the current bucket,
+// or if the series would need more than TallyOpts.MaxBuckets buckets.
+func TallyCommitsByDateSeq(
+	commits iter.Seq2[git.Commit, error],
+	opts TallyOpts,
+) iter.Seq2[TimeBucket, error] {
+	return func(yield func(TimeBucket, error) bool) {
+		if err := opts.Validate(); err != nil {
+			yield(TimeBucket{}, err)
+			return
+		}
+		if opts.Mode == LastModifiedMode || opts.Mode == FirstModifiedMode {
+			yield(
+				TimeBucket{},
+				errors.New(
+					"last-modified and first-modified modes don't work for "+
+						"timelines",
+				),
+			)
+			return
+		}
+
+		if opts.FirstCommitOnly {
+			yield(
+				TimeBucket{},
+				errors.New("cannot stream buckets when crediting first commits only"),
+			)
+			return
+		}
+
+		if opts.MergeDiffs {
+			yield(
+				TimeBucket{},
+				errors.New("cannot stream buckets when tallying merge diffs"),
+			)
+			return
+		}
+
+		opts = opts.withDecayReference()
+		resolution := opts.dateResolution()
+
+		var bucket TimeBucket
+		var started bool
+		var first time.Time
+		var numBuckets int
+		var lastGood *git.Commit
+
+		for commit, err := range commits {
+			if err != nil {
+				yield(
+					TimeBucket{},
+					iterationError(err, lastGood, resolution, opts),
+				)
+				return
+			}
+
+			lastGood = &commit
+
+			if !opts.inWindow(commit) {
+				continue
+			}
+
+			bucketedCommitTime := resolution.apply(opts.commitDate(commit))
+			if !started {
+				bucket = resolution.bucketFor(bucketedCommitTime)
+				first = bucket.Time
+				numBuckets = 1
+				started = true
+			} else if bucketedCommitTime.Before(bucket.Time) {
+				yield(
+					TimeBucket{},
+					fmt.Errorf(
+						"commit %s is out of chronological order",
+						commit.Name(),
+					),
+				)
+				return
+			}
+
+			// Close out buckets until we reach the one for this commit
+			for bucketedCommitTime.After(bucket.Time) {
+				if !yield(bucket.Rank(opts.Mode), nil) {
+					return
+				}
+
+				if numBuckets >= opts.maxBuckets() {
+					yield(
+						TimeBucket{},
+						tooManyBuckets(
+							first,
+							bucketedCommitTime,
+							opts.maxBuckets(),
+						),
+					)
+					return
+				}
+
+				t := resolution.next(bucket.Time)
+				bucket = resolution.bucketFor(t)
+				numBuckets += 1
+			}
+
+			tallyCommit(bucket.tallies, commit, opts)
+		}
+
+		if started {
+			yield(bucket.Rank(opts.Mode), nil)
+		}
+	}
+}
+
+// Returns a list of "time buckets" with tallies for each date, along with the
+// resolution of the buckets.
+//
+// The timeline runs from the first commit to the end time, if the end-time is
+// non-zero. Otherwise the end time is the time of the last commit in
+// chronological order. The resolution / size of the buckets is determined
+// based on the span holding most of the commits; see ToTimeline(). An explicit
+// resolution given in the opts takes precedence.
 //
-// The resolution / size of the buckets is determined based on the duration
-// between the first commit and end time, if the end-time is non-zero. Otherwise
-// the end time is the time of the last commit in chronological order.
+// Pass a zero end time rather than time.Now() unless the timeline should run
+// up to the present. For a history that stopped long ago, a present-day end
+// pads the timeline with empty buckets.
 func TallyCommitsTimeline(
 	commits iter.Seq2[git.Commit, error],
 	opts TallyOpts,
 	end time.Time,
-) ([]TimeBucket, error) {
+) ([]TimeBucket, ResolutionMode, error) {
 	buckets, err := TallyCommitsByDate(commits, opts)
 	if err != nil {
-		return buckets, err
+		return buckets, opts.Resolution, err
 	}
 
+	return ToTimeline(buckets, opts, end)
+}
+
+// Turns a dense series of daily buckets into a timeline at the resolution
+// given by the opts (or calculated, if the resolution is AutoResolution). A
+// calculated resolution is picked from the span holding most of the commits,
+// leaving out the oldest and newest few percent, rather than from the whole
+// timeline.
+//
+// If the end time is zero, the timeline ends with the last bucket. If the opts
+// specify a Since / Until window, the timeline spans the window instead. An
+// AsOf time in the opts overrides both the end time and Until.
+//
+// Buckets that run past a given end time (or Until / AsOf) are marked Partial,
+// since they only cover part of their period and so usually understate it.
+// Age bands are never marked Partial.
+//
+// Also returns the resolution mode used, which is never AutoResolution unless
+// there are no buckets.
+func ToTimeline(
+	buckets []TimeBucket,
+	opts TallyOpts,
+	end time.Time,
+) ([]TimeBucket, ResolutionMode, error) {
 	if len(buckets) == 0 {
-		return buckets, err
+		return buckets, opts.Resolution, nil
+	}
+
+	start := buckets[0].Time
+	if !opts.Since.IsZero() {
+		start = timeutils.Min(start, opts.Since)
 	}
 
-	if end.IsZero() {
+	// If we end at the last commit, the last bucket isn't cut short; it
+	// just has no more commits
+	endGiven := !end.IsZero() || !opts.AsOf.IsZero() || !opts.Until.IsZero()
+	if !opts.AsOf.IsZero() {
+		end = opts.AsOf
+	} else if !opts.Until.IsZero() {
+		end = opts.Until
+	} else if end.IsZero() {
 		end = buckets[len(buckets)-1].Time
 	}
 
-	resolution := CalcResolution(buckets[0].Time, end)
-	rebuckets := Rebucket(buckets, resolution, end)
+	if opts.Resolution == RelativeResolution && opts.Reference.IsZero() {
+		opts.Reference = lastCommitTime(buckets)
+	}
+
+	mode := ResolutionModeFor(opts, start, end)
+	if opts.Resolution == AutoResolution {
+		// Go by where the commits are, so that e.g. a month of activity
+		// after years of quiet isn't lost in yearly buckets
+		if activeStart, activeEnd, ok := activeSpan(buckets); ok {
+			mode = autoResolutionMode(activeStart, activeEnd)
+		}
+	}
+	opts.Resolution = mode
+	resolution := ResolutionFor(opts, start, end)
+	rebuckets, err := rebucket(
+		buckets,
+		resolution,
+		start,
+		end,
+		opts.maxBuckets(),
+	)
+	if err != nil {
+		return nil, mode, fmt.Errorf("error making timeline: %w", err)
+	}
+	if opts.TrimEmpty {
+		rebuckets = TimeSeries(rebuckets).Trim()
+	}
 
-	return rebuckets, nil
+	if opts.FractionalFiles {
+		for i, bucket := range rebuckets {
+			rebuckets[i] = bucket.shareFiles()
+		}
+	}
+
+	if endGiven && mode != RelativeResolution {
+		for i, bucket := range rebuckets {
+			rebuckets[i].Partial = bucket.EndTime.After(end)
+		}
+	}
+
+	if opts.Resolution == RelativeResolution {
+		// Newest band first
+		slices.Reverse(rebuckets)
+	}
+
+	return rebuckets, mode, nil
 }
 
+// Returns the time of the most recent commit in the buckets.
+func lastCommitTime(buckets []TimeBucket) time.Time {
+	var last time.Time
+	for _, bucket := range buckets {
+		for _, tally := range bucket.tallies {
+			last = timeutils.Max(last, tally.lastCommitTime)
+		}
+	}
+
+	if last.IsZero() {
+		return buckets[len(buckets)-1].Time
+	}
+
+	return last
+}
+
+// Returns the index of the bucket whose start time is closest to t. The
+// buckets must be non-empty.
+func nearestBucket(buckets []TimeBucket, t time.Time) int {
+	nearest := 0
+	for i, bucket := range buckets {
+		if bucket.Time.Sub(t).Abs() < buckets[nearest].Time.Sub(t).Abs() {
+			nearest = i
+		}
+	}
+
+	return nearest
+}
+
+// Re-buckets the buckets using the new resolution.
+//
+// The new buckets run from the start time to the end time, widened if
+// necessary to include all the given buckets. The given buckets do not need to
+// be in chronological order.
 func Rebucket(
 	buckets []TimeBucket,
 	resolution Resolution,
+	start time.Time,
 	end time.Time,
 ) []TimeBucket {
+	rebuckets, _ := rebucket(buckets, resolution, start, end, 0)
+	return rebuckets
+}
+
+// Like Rebucket(), but returns ErrTooManyBuckets if there would be more than
+// limit new buckets. A zero limit means no limit.
+func rebucket(
+	buckets []TimeBucket,
+	resolution Resolution,
+	start time.Time,
+	end time.Time,
+	limit int,
+) ([]TimeBucket, error) {
 	if len(buckets) < 1 {
-		return buckets
+		return buckets, nil
+	}
+
+	for _, bucket := range buckets {
+		start = timeutils.Min(start, bucket.Time)
+		end = timeutils.Max(end, bucket.Time)
+
+		// A bucket can be moved forward, e.g. a Saturday onto Monday
+		end = timeutils.Max(end, resolution.apply(bucket.Time))
 	}
 
 	rebuckets := []TimeBucket{}
+	indices := map[int64]int{} // Map of (unix) time to index in rebuckets
 
 	// Re-bucket using new resolution
-	t := resolution.apply(buckets[0].Time)
+	t := resolution.apply(start)
 	for t.Before(end) || t.Equal(end) {
-		bucket := newBucket(resolution.label(t), resolution.apply(t))
+		if limit > 0 && len(rebuckets) >= limit {
+			return nil, tooManyBuckets(start, end, limit)
+		}
+
+		bucket := resolution.bucketFor(t)
+		indices[bucket.Time.Unix()] = len(rebuckets)
 		rebuckets = append(rebuckets, bucket)
 		t = resolution.next(t)
 	}
 
-	i := 0
 	for _, bucket := range buckets {
 		rebucketedTime := resolution.apply(bucket.Time)
-		rebucket := rebuckets[i]
-		if rebucketedTime.After(rebucket.Time) {
-			// Next bucket, might have to skip some empty ones
-			for !rebucketedTime.Equal(rebucket.Time) {
-				i += 1
-				rebucket = rebuckets[i]
-			}
+		i, ok := indices[rebucketedTime.Unix()]
+		if !ok {
+			i = nearestBucket(rebuckets, rebucketedTime)
+			logger().Warn(
+				"no bucket found for time when rebucketing; using nearest bucket",
+				"time", rebucketedTime,
+				"nearest", rebuckets[i].Time,
+			)
 		}
 
-		bucket.Time = rebucket.Time
-		bucket.Name = rebucket.Name
-		rebuckets[i] = rebuckets[i].Combine(bucket)
+		bucket.Time = rebuckets[i].Time
+		bucket.Name = rebuckets[i].Name
+		rebuckets[i] = rebuckets[i].merge(bucket)
 	}
 
-	return rebuckets
+	return rebuckets, nil
 }
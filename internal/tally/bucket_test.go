@@ -1,10 +1,19 @@
 package tally
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
 )
@@ -60,7 +69,10 @@ func TestTimeSeriesCombine(t *testing.T) {
 		},
 	}
 
-	c := a.Combine(b)
+	c, err := a.Combine(b)
+	if err != nil {
+		t.Fatalf("Combine() returned error: %v", err)
+	}
 	expected := TimeSeries{
 		TimeBucket{
 			Name: "2024-04-01",
@@ -136,6 +148,242 @@ func TestTimeSeriesCombine(t *testing.T) {
 	}
 }
 
+// Builds a monthly series for 2024 with one bucket per month given. Months
+// with no commits are empty buckets.
+func monthlySeries(months []time.Month, commits []int) TimeSeries {
+	series := TimeSeries{}
+	for i, month := range months {
+		bucket := monthlyIn(time.Local).bucketFor(
+			time.Date(2024, month, 1, 0, 0, 0, 0, time.Local),
+		)
+		if commits[i] > 0 {
+			bucket.tallies["bob"] = Tally{name: "bob", numTallied: commits[i]}
+		}
+		series = append(series, bucket)
+	}
+
+	return series
+}
+
+func TestTimeSeriesCombineDifferentLengths(t *testing.T) {
+	a := monthlySeries(
+		[]time.Month{time.January, time.February, time.March},
+		[]int{1, 2, 3},
+	)
+	b := monthlySeries(
+		[]time.Month{time.February, time.March, time.April},
+		[]int{10, 20, 30},
+	)
+
+	c, err := a.Combine(b)
+	if err != nil {
+		t.Fatalf("Combine() returned error: %v", err)
+	}
+
+	names := []string{}
+	commits := []int{}
+	for _, bucket := range c {
+		names = append(names, bucket.Name)
+		commits = append(commits, bucket.tallies["bob"].numTallied)
+	}
+
+	expectedNames := []string{"Jan 2024", "Feb 2024", "Mar 2024", "Apr 2024"}
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Errorf("combined buckets are wrong:\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{1, 12, 23, 30}, commits); diff != "" {
+		t.Errorf("combined tallies are wrong:\n%s", diff)
+	}
+}
+
+func TestTimeSeriesCombinePadded(t *testing.T) {
+	padded := monthlySeries(
+		[]time.Month{time.January, time.February, time.March, time.April},
+		[]int{0, 5, 0, 0},
+	)
+	unpadded := monthlySeries(
+		[]time.Month{time.February, time.March},
+		[]int{1, 2},
+	)
+
+	expected := []string{"Jan 2024", "Feb 2024", "Mar 2024", "Apr 2024"}
+	for _, order := range [][2]TimeSeries{
+		{padded, unpadded},
+		{unpadded, padded},
+	} {
+		c, err := order[0].Combine(order[1])
+		if err != nil {
+			t.Fatalf("Combine() returned error: %v", err)
+		}
+
+		names := []string{}
+		commits := []int{}
+		for _, bucket := range c {
+			names = append(names, bucket.Name)
+			commits = append(commits, bucket.tallies["bob"].numTallied)
+		}
+
+		if diff := cmp.Diff(expected, names); diff != "" {
+			t.Errorf("combined buckets are wrong:\n%s", diff)
+		}
+		if diff := cmp.Diff([]int{0, 6, 2, 0}, commits); diff != "" {
+			t.Errorf("combined tallies are wrong:\n%s", diff)
+		}
+
+		trimmed := []string{}
+		for _, bucket := range c.Trim() {
+			trimmed = append(trimmed, bucket.Name)
+		}
+		if diff := cmp.Diff(expected[1:3], trimmed); diff != "" {
+			t.Errorf("trimmed buckets are wrong:\n%s", diff)
+		}
+	}
+}
+
+func TestTimeBucketCombineDoesNotModifyInputs(t *testing.T) {
+	day := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	a := newBucket("2024-04-01", day)
+	a.tallies["alice"] = Tally{
+		name:       "alice",
+		numTallied: 1,
+		fileset:    map[string]bool{"a.go": true},
+	}
+
+	b := newBucket("2024-04-01", day)
+	b.tallies["alice"] = Tally{
+		name:       "alice",
+		numTallied: 2,
+		fileset:    map[string]bool{"b.go": true},
+	}
+	b.tallies["bob"] = Tally{name: "bob", numTallied: 1}
+
+	for range 2 {
+		combined, err := a.Combine(b)
+		if err != nil {
+			t.Fatalf("Combine() returned error: %v", err)
+		}
+
+		alice := combined.tallies["alice"]
+		if alice.numTallied != 3 || len(alice.fileset) != 2 {
+			t.Errorf("combined tally for alice is wrong: %+v", alice)
+		}
+	}
+
+	if len(a.tallies) != 1 {
+		t.Errorf(
+			"expected original bucket to have 1 tally but got %d",
+			len(a.tallies),
+		)
+	}
+	if a.tallies["alice"].numTallied != 1 {
+		t.Errorf("original tally for alice was modified")
+	}
+	if len(a.tallies["alice"].fileset) != 1 {
+		t.Errorf("original fileset for alice was modified")
+	}
+	if len(b.tallies["alice"].fileset) != 1 {
+		t.Errorf("fileset for alice in other bucket was modified")
+	}
+}
+
+func TestTimeSeriesCombineMatchesSerial(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	authors := []string{"alice", "bob", "jim"}
+
+	commits := []git.Commit{}
+	for i := range 60 {
+		commits = append(commits, git.Commit{
+			ShortHash:  fmt.Sprintf("%07x", i),
+			AuthorName: authors[i*i%len(authors)],
+			Date:       start.AddDate(0, 0, i*3%45),
+			FileDiffs: []git.FileDiff{
+				{
+					Path:       fmt.Sprintf("file%d.go", i%7),
+					LinesAdded: i % 11,
+				},
+			},
+		})
+	}
+	opts := TallyOpts{
+		Mode: LinesMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	serial, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	// Tally in shards like the concurrent package does, then combine
+	var combined TimeSeries
+	for shard := range slices.Chunk(commits, 16) {
+		buckets, err := TallyCommitsByDate(
+			iterutils.WithoutErrors(slices.Values(shard)),
+			opts,
+		)
+		if err != nil {
+			t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+		}
+
+		combined, err = combined.Combine(buckets)
+		if err != nil {
+			t.Fatalf("Combine() returned error: %v", err)
+		}
+	}
+	parallel, _, err := ToTimeline(combined, opts, time.Time{})
+	if err != nil {
+		t.Fatalf("ToTimeline() returned error: %v", err)
+	}
+
+	if len(serial) != len(parallel) {
+		t.Fatalf(
+			"expected %d buckets but got %d",
+			len(serial),
+			len(parallel),
+		)
+	}
+
+	for i := range serial {
+		a := serial[i].Rank(opts.Mode)
+		b := parallel[i].Rank(opts.Mode)
+		if a.Name != b.Name {
+			t.Errorf("bucket %d: expected name %s but got %s", i, a.Name, b.Name)
+		}
+		if diff := cmp.Diff(a.Tally, b.Tally); diff != "" {
+			t.Errorf("bucket %d: winning tally differs:\n%s", i, diff)
+		}
+		if diff := cmp.Diff(a.TotalTally, b.TotalTally); diff != "" {
+			t.Errorf("bucket %d: total tally differs:\n%s", i, diff)
+		}
+	}
+}
+
+func TestTimeSeriesCombineMismatchedNames(t *testing.T) {
+	a := TimeSeries{
+		TimeBucket{
+			Name:    "Apr 2024",
+			Time:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+			tallies: map[string]Tally{"alice": {added: 3}},
+		},
+	}
+	b := TimeSeries{
+		TimeBucket{
+			Name:    "2024-W14",
+			Time:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+			tallies: map[string]Tally{"bob": {added: 2}},
+		},
+	}
+
+	_, err := a.Combine(b)
+	if err == nil {
+		t.Errorf("expected Combine() to return error for mismatched buckets")
+	}
+}
+
 func TestTallyCommitsTimelineEmpty(t *testing.T) {
 	seq := iterutils.WithoutErrors(slices.Values([]git.Commit{}))
 	opts := TallyOpts{
@@ -144,7 +392,7 @@ func TestTallyCommitsTimelineEmpty(t *testing.T) {
 	}
 	end := time.Now()
 
-	buckets, err := TallyCommitsTimeline(seq, opts, end)
+	buckets, _, err := TallyCommitsTimeline(seq, opts, end)
 	if err != nil {
 		t.Errorf("TallyCommitsTimeline() returned error: %v", err)
 	}
@@ -156,3 +404,3853 @@ func TestTallyCommitsTimelineEmpty(t *testing.T) {
 		)
 	}
 }
+
+func TestWeeklyResolution(t *testing.T) {
+	weekly := weeklyIn(time.Local)
+	wednesday := time.Date(2024, 1, 17, 15, 30, 0, 0, time.Local)
+
+	bucketed := weekly.apply(wednesday)
+	expected := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	if !bucketed.Equal(expected) {
+		t.Errorf("expected week to start on %v but got %v", expected, bucketed)
+	}
+
+	next := weekly.next(wednesday)
+	expected = time.Date(2024, 1, 22, 0, 0, 0, 0, time.Local)
+	if !next.Equal(expected) {
+		t.Errorf("expected next week to start on %v but got %v", expected, next)
+	}
+
+	label := weekly.label(wednesday)
+	if label != "2024-W03" {
+		t.Errorf("expected label \"2024-W03\" but got \"%s\"", label)
+	}
+}
+
+func TestQuarterlyResolution(t *testing.T) {
+	quarterly := quarterlyIn(time.Local, time.January)
+	may := time.Date(2024, 5, 20, 0, 0, 0, 0, time.Local)
+
+	bucketed := quarterly.apply(may)
+	expected := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	if !bucketed.Equal(expected) {
+		t.Errorf("expected quarter to start on %v but got %v", expected, bucketed)
+	}
+
+	next := quarterly.next(may)
+	expected = time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	if !next.Equal(expected) {
+		t.Errorf("expected next quarter to start on %v but got %v", expected, next)
+	}
+
+	label := quarterly.label(may)
+	if label != "2024 Q2" {
+		t.Errorf("expected label \"2024 Q2\" but got \"%s\"", label)
+	}
+}
+
+func TestFiscalResolution(t *testing.T) {
+	aug := time.Date(2023, 8, 20, 0, 0, 0, 0, time.Local)
+	mar := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+
+	yearly := yearlyIn(time.Local, time.July)
+	expected := time.Date(2023, 7, 1, 0, 0, 0, 0, time.Local)
+	for _, t1 := range []time.Time{aug, mar} {
+		if bucketed := yearly.apply(t1); !bucketed.Equal(expected) {
+			t.Errorf("expected fiscal year to start on %v but got %v", expected, bucketed)
+		}
+		if label := yearly.label(t1); label != "FY2024" {
+			t.Errorf("expected label \"FY2024\" but got \"%s\"", label)
+		}
+	}
+
+	next := yearly.next(mar)
+	expected = time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	if !next.Equal(expected) {
+		t.Errorf("expected next fiscal year to start on %v but got %v", expected, next)
+	}
+
+	quarterly := quarterlyIn(time.Local, time.July)
+	bucketed := quarterly.apply(mar)
+	expected = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	if !bucketed.Equal(expected) {
+		t.Errorf("expected fiscal quarter to start on %v but got %v", expected, bucketed)
+	}
+	if label := quarterly.label(mar); label != "FY2024 Q3" {
+		t.Errorf("expected label \"FY2024 Q3\" but got \"%s\"", label)
+	}
+	if label := quarterly.label(aug); label != "FY2024 Q1" {
+		t.Errorf("expected label \"FY2024 Q1\" but got \"%s\"", label)
+	}
+
+	// Offset from calendar quarters
+	quarterly = quarterlyIn(time.Local, time.February)
+	bucketed = quarterly.apply(mar)
+	expected = time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)
+	if !bucketed.Equal(expected) {
+		t.Errorf("expected fiscal quarter to start on %v but got %v", expected, bucketed)
+	}
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	if label := quarterly.label(jan); label != "FY2024 Q4" {
+		t.Errorf("expected label \"FY2024 Q4\" but got \"%s\"", label)
+	}
+}
+
+func TestIntervalResolution(t *testing.T) {
+	anchor := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	interval := intervalIn(time.Local, 14, anchor)
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		start time.Time
+		next  time.Time
+		label string
+	}{
+		{
+			name:  "on_anchor",
+			t:     anchor,
+			start: anchor,
+			next:  time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local),
+			label: "2024-01-01 – 2024-01-14",
+		},
+		{
+			name:  "after_anchor",
+			t:     time.Date(2024, 1, 20, 15, 0, 0, 0, time.Local),
+			start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local),
+			next:  time.Date(2024, 1, 29, 0, 0, 0, 0, time.Local),
+			label: "2024-01-15 – 2024-01-28",
+		},
+		{
+			name:  "before_anchor",
+			t:     time.Date(2023, 12, 25, 9, 0, 0, 0, time.Local),
+			start: time.Date(2023, 12, 18, 0, 0, 0, 0, time.Local),
+			next:  anchor,
+			label: "2023-12-18 – 2023-12-31",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if start := interval.apply(test.t); !start.Equal(test.start) {
+				t.Errorf("expected interval to start on %v but got %v", test.start, start)
+			}
+
+			if next := interval.next(test.t); !next.Equal(test.next) {
+				t.Errorf("expected next interval to start on %v but got %v", test.next, next)
+			}
+
+			if label := interval.label(test.t); label != test.label {
+				t.Errorf("expected label \"%s\" but got \"%s\"", test.label, label)
+			}
+		})
+	}
+}
+
+func TestRelativeResolution(t *testing.T) {
+	reference := time.Date(2024, 6, 30, 15, 0, 0, 0, time.Local)
+	relative := relativeIn(time.Local, reference)
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		start time.Time
+		next  time.Time
+		label string
+	}{
+		{
+			name:  "newest",
+			t:     time.Date(2024, 6, 20, 9, 0, 0, 0, time.Local),
+			start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local),
+			next:  time.Unix(1<<62, 0),
+			label: "0–30 days ago",
+		},
+		{
+			name:  "after_reference",
+			t:     time.Date(2024, 7, 2, 9, 0, 0, 0, time.Local),
+			start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local),
+			next:  time.Unix(1<<62, 0),
+			label: "0–30 days ago",
+		},
+		{
+			name:  "band_edge",
+			t:     time.Date(2024, 5, 31, 9, 0, 0, 0, time.Local),
+			start: time.Date(2024, 4, 2, 0, 0, 0, 0, time.Local),
+			next:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local),
+			label: "30–90 days ago",
+		},
+		{
+			name:  "years",
+			t:     time.Date(2022, 8, 1, 0, 0, 0, 0, time.Local),
+			start: time.Date(2022, 7, 2, 0, 0, 0, 0, time.Local),
+			next:  time.Date(2023, 7, 2, 0, 0, 0, 0, time.Local),
+			label: "1–2 years ago",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if start := relative.apply(test.t); !start.Equal(test.start) {
+				t.Errorf("expected band to start on %v but got %v", test.start, start)
+			}
+
+			if next := relative.next(test.t); !next.Equal(test.next) {
+				t.Errorf("expected next band to start on %v but got %v", test.next, next)
+			}
+
+			if label := relative.label(test.t); label != test.label {
+				t.Errorf("expected label \"%s\" but got \"%s\"", test.label, label)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsTimelineRelative(t *testing.T) {
+	commits := []git.Commit{
+		{
+			Hash:        "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        time.Date(2023, 12, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			Hash:        "bab",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorEmail },
+		Resolution: RelativeResolution,
+	}
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, b := range buckets {
+		names = append(names, b.Name)
+	}
+
+	expected := []string{
+		"0–30 days ago",
+		"30–90 days ago",
+		"90–180 days ago",
+		"180–365 days ago",
+	}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("buckets are wrong:\n%s", diff)
+	}
+	if buckets[0].CommitCount() != 1 || buckets[3].CommitCount() != 1 {
+		t.Errorf("expected one commit in the first and last bands")
+	}
+}
+
+func TestTimeBucketEndTime(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 31, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 3, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: MonthlyResolution,
+	}
+
+	daily, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	expected := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)
+	if !daily[0].EndTime.Equal(expected) {
+		t.Errorf("expected daily bucket to end %v but got %v", expected, daily[0].EndTime)
+	}
+
+	monthly, _, err := ToTimeline(daily, opts, time.Time{})
+	if err != nil {
+		t.Fatalf("ToTimeline() returned error: %v", err)
+	}
+	for _, bucket := range monthly {
+		if !bucket.EndTime.Equal(bucket.Time.AddDate(0, 1, 0)) {
+			t.Errorf(
+				"expected %s to end a month after %v but got %v",
+				bucket.Name,
+				bucket.Time,
+				bucket.EndTime,
+			)
+		}
+	}
+
+	reference := time.Date(2024, 6, 30, 15, 0, 0, 0, time.Local)
+	newest := relativeIn(time.Local, reference).bucketFor(reference)
+	expected = time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	if !newest.EndTime.Equal(expected) {
+		t.Errorf("expected newest band to end %v but got %v", expected, newest.EndTime)
+	}
+}
+
+func TestTallyCommitsTimelineResolutionMode(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2022, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 3, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	_, mode, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if mode != QuarterlyResolution {
+		t.Errorf("expected quarterly resolution but got %s", mode)
+	}
+
+	opts.Resolution = MonthlyResolution
+	_, mode, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if mode != MonthlyResolution {
+		t.Errorf("expected monthly resolution but got %s", mode)
+	}
+}
+
+func TestTallyCommitsTimelineResolutionOverridesAuto(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 20, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, mode, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if mode != WeeklyResolution || buckets[0].Name != "2024-W01" {
+		t.Fatalf(
+			"expected auto mode to pick weekly buckets but got %s (%s)",
+			mode,
+			buckets[0].Name,
+		)
+	}
+
+	opts.Resolution = MonthlyResolution
+	buckets, _, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, bucket := range buckets {
+		names = append(names, bucket.Name)
+	}
+	expected := []string{"Jan 2024", "Feb 2024", "Mar 2024", "Apr 2024"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("buckets are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsTimelineAutoResolutionDensity(t *testing.T) {
+	// One old commit, then a burst of activity years later
+	commits := []git.Commit{
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2019, 6, 1, 9, 0, 0, 0, time.Local),
+		},
+	}
+	for i := range 30 {
+		commits = append(commits, git.Commit{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 3, 1+i, 9, 0, 0, 0, time.Local),
+		})
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, mode, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	if mode != DailyResolution {
+		t.Errorf("expected daily resolution but got %s", mode)
+	}
+
+	// The old commit is still on the timeline
+	if buckets[0].Name != "2019-06-01" {
+		t.Errorf(
+			"expected timeline to start on 2019-06-01 but got %s",
+			buckets[0].Name,
+		)
+	}
+}
+
+func TestTallyCommitsTimelineRetainCommits(t *testing.T) {
+	commits := []git.Commit{
+		{
+			Hash:       "bbb",
+			Subject:    "Fix parser",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 20, 9, 0, 0, 0, time.Local),
+			CoAuthors:  []git.Author{{Name: "alice", Email: "alice@mail.com"}},
+		},
+		{
+			Hash:       "aaa",
+			Subject:    "Add parser",
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			Hash:       "ccc",
+			Subject:    "Release",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 2, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:          CommitMode,
+		Key:           func(c git.Commit) string { return c.AuthorName },
+		Resolution:    MonthlyResolution,
+		CoAuthors:     true,
+		RetainCommits: true,
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	// Oldest first, and the co-authored commit only once
+	expected := []CommitRef{
+		{Hash: "aaa", Subject: "Add parser", Time: commits[1].Date},
+		{Hash: "bbb", Subject: "Fix parser", Time: commits[0].Date},
+	}
+	if diff := cmp.Diff(expected, buckets[0].Commits()); diff != "" {
+		t.Errorf("January commits are wrong:\n%s", diff)
+	}
+	if n := len(buckets[1].Commits()); n != 1 {
+		t.Errorf("expected 1 commit in February but got %d", n)
+	}
+
+	opts.RetainCommits = false
+	buckets, _, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if n := len(buckets[0].Commits()); n != 0 {
+		t.Errorf("expected no commits without RetainCommits but got %d", n)
+	}
+}
+
+func TestTallyCommitsTimelineLabelFormats(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 2, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:         CommitMode,
+		Key:          func(c git.Commit) string { return c.AuthorName },
+		Resolution:   MonthlyResolution,
+		LabelFormats: map[ResolutionMode]string{MonthlyResolution: "01/2006"},
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, bucket := range buckets {
+		names = append(names, bucket.Name)
+	}
+	if diff := cmp.Diff([]string{"01/2024", "02/2024"}, names); diff != "" {
+		t.Errorf("bucket names are wrong:\n%s", diff)
+	}
+
+	// Interval buckets show the first and last day
+	opts.Resolution = IntervalResolution
+	opts.Interval = 14
+	opts.Anchor = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	opts.LabelFormats = map[ResolutionMode]string{IntervalResolution: "Jan 2"}
+	buckets, _, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if buckets[0].Name != "Jan 1 – Jan 14" {
+		t.Errorf(
+			"expected interval label Jan 1 – Jan 14 but got %s",
+			buckets[0].Name,
+		)
+	}
+}
+
+func TestTallyCommitsTimelineReleases(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 5, 15, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 2, 10, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "carol",
+			Date:       time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: ReleaseResolution,
+		Releases: []Release{
+			{Name: "v1.0", Time: time.Date(2024, 1, 5, 12, 0, 0, 0, time.Local)},
+			{Name: "v1.1", Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)},
+		},
+	}
+
+	buckets, mode, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if mode != ReleaseResolution {
+		t.Errorf("expected release resolution but got %s", mode)
+	}
+
+	type result struct {
+		Name    string
+		Winner  string
+		Commits int
+	}
+	results := []result{}
+	for _, bucket := range buckets {
+		bucket = bucket.Rank(CommitMode)
+		results = append(results, result{
+			Name:    bucket.Name,
+			Winner:  bucket.Tally.AuthorName,
+			Commits: bucket.TotalTally.Commits,
+		})
+	}
+
+	// The release on Jan 5 splits that day's commits
+	expected := []result{
+		{Name: "..v1.0", Winner: "bob", Commits: 1},
+		{Name: "v1.0..v1.1", Winner: "alice", Commits: 2},
+		{Name: "v1.1..", Winner: "carol", Commits: 1},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("buckets are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsTimelineBusinessDays(t *testing.T) {
+	// Jan 6 and 7, 2024 are a Saturday and Sunday
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 4, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "carol",
+			Date:       time.Date(2024, 1, 6, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "carol",
+			Date:       time.Date(2024, 1, 7, 9, 0, 0, 0, time.Local),
+		},
+	}
+
+	type result struct {
+		Name    string
+		Commits int
+	}
+	tests := []struct {
+		name     string
+		toFriday bool
+		expected []result
+	}{
+		{
+			name: "to_monday",
+			expected: []result{
+				{Name: "2024-01-04", Commits: 1},
+				{Name: "2024-01-05", Commits: 1},
+				{Name: "2024-01-08", Commits: 2},
+			},
+		},
+		{
+			name:     "to_friday",
+			toFriday: true,
+			expected: []result{
+				{Name: "2024-01-04", Commits: 1},
+				{Name: "2024-01-05", Commits: 3},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:            CommitMode,
+				Key:             func(c git.Commit) string { return c.AuthorName },
+				Resolution:      BusinessDayResolution,
+				WeekendToFriday: test.toFriday,
+			}
+
+			buckets, mode, err := TallyCommitsTimeline(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+				time.Time{},
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+			}
+			if mode != BusinessDayResolution {
+				t.Errorf("expected business-day resolution but got %s", mode)
+			}
+
+			results := []result{}
+			for _, bucket := range buckets {
+				bucket = bucket.Rank(CommitMode)
+				results = append(results, result{
+					Name:    bucket.Name,
+					Commits: bucket.TotalTally.Commits,
+				})
+			}
+
+			if diff := cmp.Diff(test.expected, results); diff != "" {
+				t.Errorf("buckets are wrong:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsTimelineTooManyBuckets(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(1970, 1, 1, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		MaxBuckets: 1000,
+	}
+
+	_, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("expected ErrTooManyBuckets for skewed commit but got %v", err)
+	}
+
+	// An end far in the future is caught by the default limit too
+	opts.MaxBuckets = 0
+	opts.Resolution = DailyResolution
+	_, _, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits[1:])),
+		opts,
+		time.Date(3000, 1, 1, 0, 0, 0, 0, time.Local),
+	)
+	if !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("expected ErrTooManyBuckets for far end but got %v", err)
+	}
+}
+
+func TestTallyCommitsTimelinePartial(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 3, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: MonthlyResolution,
+	}
+
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		end,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	partial := []bool{}
+	for _, bucket := range buckets {
+		partial = append(partial, bucket.Partial)
+	}
+	if diff := cmp.Diff([]bool{false, false, true}, partial); diff != "" {
+		t.Errorf("partial buckets are wrong:\n%s", diff)
+	}
+
+	// Ending at the last commit doesn't cut the last bucket short
+	buckets, _, err = TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+	if buckets[len(buckets)-1].Partial {
+		t.Errorf("expected last bucket not to be partial")
+	}
+}
+
+func TestTallyCommitsByClock(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 1, 9, 30, 0, 0, time.UTC), // Mon
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 8, 9, 5, 0, 0, time.UTC), // Mon
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 4, 7, 23, 0, 0, 0, time.UTC), // Sun
+		},
+	}
+	opts := TallyOpts{
+		Mode:     CommitMode,
+		Key:      func(c git.Commit) string { return c.AuthorName },
+		Location: time.UTC,
+	}
+
+	hours, err := TallyCommitsByClock(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		HourOfDay,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByClock() returned error: %v", err)
+	}
+
+	if len(hours) != 24 {
+		t.Fatalf("expected 24 buckets but got %d", len(hours))
+	}
+	if hours[9].Name != "09:00" || hours[9].Rank(opts.Mode).Tally.Commits != 2 {
+		t.Errorf("expected bob's 2 commits at 09:00 but got %+v", hours[9])
+	}
+	if hours[23].Rank(opts.Mode).Tally.AuthorName != "alice" {
+		t.Errorf("expected alice to win 23:00")
+	}
+
+	days, err := TallyCommitsByClock(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		DayOfWeek,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByClock() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, bucket := range days {
+		names = append(names, bucket.Name)
+	}
+	expected := []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("day buckets are wrong:\n%s", diff)
+	}
+	if days[0].CommitCount() != 2 || days[6].CommitCount() != 1 {
+		t.Errorf("commits are in the wrong days")
+	}
+
+	// Hours are in the configured time zone
+	opts.Location = time.FixedZone("UTC+2", 2*60*60)
+	hours, err = TallyCommitsByClock(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		HourOfDay,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByClock() returned error: %v", err)
+	}
+	if hours[1].CommitCount() != 1 {
+		t.Errorf("expected alice's commit at 01:00 in UTC+2")
+	}
+}
+
+func TestResolutionUsesLocation(t *testing.T) {
+	lateUTC := time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)
+
+	utc := dailyIn(time.UTC)
+	if label := utc.label(lateUTC); label != "2024-03-10" {
+		t.Errorf("expected UTC label \"2024-03-10\" but got \"%s\"", label)
+	}
+
+	tokyo := dailyIn(time.FixedZone("UTC+9", 9*60*60))
+	if label := tokyo.label(lateUTC); label != "2024-03-11" {
+		t.Errorf("expected UTC+9 label \"2024-03-11\" but got \"%s\"", label)
+	}
+}
+
+func TestTallyCommitsByDateSeq(t *testing.T) {
+	commits := []git.Commit{
+		{
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local),
+		},
+		{
+			ShortHash:   "bab",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 4, 3, 9, 0, 0, 0, time.Local),
+		},
+		{
+			ShortHash:   "bac",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 4, 3, 17, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	seq := TallyCommitsByDateSeq(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	buckets, err := iterutils.Collect(seq)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDateSeq() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, bucket := range buckets {
+		names = append(names, bucket.Name)
+	}
+	expectedNames := []string{"2024-04-01", "2024-04-02", "2024-04-03"}
+	if !slices.Equal(names, expectedNames) {
+		t.Fatalf("expected buckets %v but got %v", expectedNames, names)
+	}
+
+	if buckets[0].Tally.AuthorName != "bob" {
+		t.Errorf("expected bob to win first bucket")
+	}
+	if buckets[1].TotalTally.Commits != 0 {
+		t.Errorf("expected second bucket to be empty")
+	}
+	if buckets[2].Tally.AuthorName != "alice" || buckets[2].Tally.Commits != 2 {
+		t.Errorf("expected alice to win last bucket with 2 commits")
+	}
+}
+
+func TestTallyCommitsByDateSeqOutOfOrder(t *testing.T) {
+	commits := []git.Commit{
+		{
+			ShortHash:  "baa",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 3, 9, 0, 0, 0, time.Local),
+		},
+		{
+			ShortHash:  "bab",
+			AuthorName: "alice",
+			Date:       time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	seq := TallyCommitsByDateSeq(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	_, err := iterutils.Collect(seq)
+	if err == nil {
+		t.Errorf("expected error for out-of-order commits")
+	}
+}
+
+func TestTallyCommitsByDateSeqTooManyBuckets(t *testing.T) {
+	commits := []git.Commit{
+		{
+			ShortHash:  "baa",
+			AuthorName: "bob",
+			Date:       time.Date(1970, 1, 1, 9, 0, 0, 0, time.Local),
+		},
+		{
+			ShortHash:  "bab",
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 5, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		MaxBuckets: 1000,
+	}
+
+	seq := TallyCommitsByDateSeq(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	var numBuckets int
+	var err error
+	for _, err = range seq {
+		if err != nil {
+			break
+		}
+		numBuckets += 1
+	}
+	if !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("expected ErrTooManyBuckets but got %v", err)
+	}
+	if numBuckets > 1000 {
+		t.Errorf("expected at most 1000 buckets but got %d", numBuckets)
+	}
+}
+
+func TestTallyCommitsByDateSeqValidates(t *testing.T) {
+	commits := []git.Commit{
+		{
+			ShortHash:  "baa",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local),
+		},
+	}
+
+	seq := TallyCommitsByDateSeq(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		TallyOpts{Mode: CommitMode}, // No Key
+	)
+	_, err := iterutils.Collect(seq)
+	if err == nil || !strings.Contains(err.Error(), "invalid tally options") {
+		t.Errorf("expected invalid options error but got %v", err)
+	}
+}
+
+func TestTallyCommitsByDateSlice(t *testing.T) {
+	commits := []git.Commit{
+		{
+			ShortHash:  "baa",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 3, 9, 0, 0, 0, time.Local),
+		},
+		{
+			ShortHash:  "bab",
+			AuthorName: "alice",
+			Date:       time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, err := TallyCommitsByDateSlice(
+		commits,
+		opts,
+		time.Date(2024, 4, 5, 0, 0, 0, 0, time.Local),
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDateSlice() returned error: %v", err)
+	}
+
+	if commits[0].ShortHash != "baa" {
+		t.Errorf("expected the given commits to be left in their order")
+	}
+
+	type result struct {
+		Name   string
+		Winner string
+	}
+	results := []result{}
+	for _, bucket := range buckets {
+		bucket = bucket.Rank(CommitMode)
+		results = append(results, result{bucket.Name, bucket.Tally.AuthorName})
+	}
+
+	expected := []result{
+		{Name: "2024-04-01", Winner: "alice"},
+		{Name: "2024-04-02"},
+		{Name: "2024-04-03", Winner: "bob"},
+		{Name: "2024-04-04"},
+		{Name: "2024-04-05"},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("buckets are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsByDateIterationError(t *testing.T) {
+	commits := func(yield func(git.Commit, error) bool) {
+		commit := git.Commit{
+			ShortHash:  "baa",
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 3, 9, 0, 0, 0, time.Local),
+		}
+		if !yield(commit, nil) {
+			return
+		}
+
+		yield(git.Commit{}, errors.New("malformed diff"))
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, err := TallyCommitsByDate(commits, opts)
+	if err == nil {
+		t.Fatalf("expected error from commit iterator")
+	}
+	if !errors.Is(err, ErrIncomplete) {
+		t.Errorf("expected ErrIncomplete but got: %v", err)
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "after commit baa (bucket 2024-04-03)") {
+		t.Errorf("expected error to name last good commit but got: %s", msg)
+	}
+
+	// The commit before the error is still tallied
+	if len(buckets) != 1 || buckets[0].CommitCount() != 1 {
+		t.Errorf("expected partial result with 1 commit but got %v", buckets)
+	}
+}
+
+func TestTallyCommitsByDateIterationErrorFirst(t *testing.T) {
+	cause := errors.New("bad revision")
+	commits := func(yield func(git.Commit, error) bool) {
+		yield(git.Commit{}, cause)
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, err := TallyCommitsByDate(commits, opts)
+	if !errors.Is(err, ErrIncomplete) || !errors.Is(err, cause) {
+		t.Fatalf("expected incomplete error wrapping cause but got: %v", err)
+	}
+	if len(buckets) != 0 {
+		t.Errorf("expected no buckets but got %d", len(buckets))
+	}
+}
+
+func TestTallyCommitsByDateAuthorFilter(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "Bob Smith", AuthorEmail: "bob@mail.com", Date: day},
+		{AuthorName: "Alice", AuthorEmail: "alice@mail.com", Date: day},
+		{AuthorName: "Jim", AuthorEmail: "jim@mail.com", Date: day},
+	}
+	opts := TallyOpts{
+		Mode:         CommitMode,
+		Key:          func(c git.Commit) string { return c.AuthorEmail },
+		AuthorFilter: []string{"Smith", "alice@mail.com"},
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.CommitCount() != 2 {
+		t.Errorf("expected commit count of 2 but got %d", bucket.CommitCount())
+	}
+
+	if bucket.TotalTally.Commits != 2 {
+		t.Errorf(
+			"expected 2 commits in total but got %d",
+			bucket.TotalTally.Commits,
+		)
+	}
+	if _, ok := bucket.tallies["jim@mail.com"]; ok {
+		t.Errorf("expected jim to be filtered out")
+	}
+}
+
+func TestTallyCommitsByDateMessageFilter(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{Hash: "a", AuthorName: "bob", Subject: "feat: add foo", Date: day},
+		{Hash: "b", AuthorName: "bob", Subject: "fix: foo", Date: day},
+		{Hash: "c", AuthorName: "alice", Subject: "fix: bar", Date: day},
+		{Hash: "d", AuthorName: "alice", Subject: "docs: feat: x", Date: day},
+	}
+	opts := TallyOpts{
+		Mode:          CommitMode,
+		Key:           func(c git.Commit) string { return c.AuthorName },
+		MessageFilter: regexp.MustCompile(`^feat:`),
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.Tally.AuthorName != "bob" || bucket.Tally.Commits != 1 {
+		t.Errorf("expected bob to win with 1 commit but got %+v", bucket.Tally)
+	}
+	if bucket.TotalTally.Commits != 1 {
+		t.Errorf(
+			"expected 1 commit in total but got %d",
+			bucket.TotalTally.Commits,
+		)
+	}
+}
+
+func TestTallyCommitsByDateSignatures(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{Hash: "a", AuthorName: "bob", Date: day, Signature: git.GoodSignature},
+		{Hash: "b", AuthorName: "bob", Date: day, Signature: git.BadSignature},
+		{Hash: "c", AuthorName: "alice", Date: day, Signature: git.NoSignature},
+		{Hash: "d", AuthorName: "alice", Date: day},
+	}
+	opts := TallyOpts{
+		Mode:         CommitMode,
+		Key:          func(c git.Commit) string { return c.AuthorName },
+		VerifiedOnly: true,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.TotalTally.Commits != 1 || bucket.Tally.AuthorName != "bob" {
+		t.Errorf(
+			"expected 1 verified commit by bob but got %+v",
+			bucket.TotalTally,
+		)
+	}
+
+	opts.VerifiedOnly = false
+	opts.GroupBy = SignatureGroup
+	buckets, err = TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	commitsByGroup := map[string]int{}
+	for tally := range buckets[0].Tallies() {
+		commitsByGroup[tally.AuthorName] = tally.Commits
+	}
+	expected := map[string]int{SignedGroup: 2, UnsignedGroup: 2}
+	if diff := cmp.Diff(expected, commitsByGroup); diff != "" {
+		t.Errorf("signature groups are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsByDateExtensions(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 4, LinesRemoved: 1},
+				{Path: "README.md", LinesAdded: 10},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "docs/intro.md", LinesAdded: 7},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:       LinesMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Extensions: []string{".go"},
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	expected := FinalTally{
+		AuthorName:      "bob",
+		Commits:         1,
+		LinesAdded:      4,
+		LinesRemoved:    1,
+		FileCount:       1,
+		Churn:           5,
+		FirstCommitTime: day,
+		LastCommitTime:  day,
+	}
+	if diff := cmp.Diff(expected, bucket.Tally); diff != "" {
+		t.Errorf("bucket tally is wrong:\n%s", diff)
+	}
+	if _, ok := bucket.tallies["alice"]; ok {
+		t.Errorf("expected alice's commit to be filtered out")
+	}
+}
+
+func TestTallyCommitsByDatePathFilter(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "internal/tally/tally.go", LinesAdded: 3},
+				{Path: "internal/tally/sub/x.go", LinesAdded: 2},
+				{Path: "internal/git/git.go", LinesAdded: 10},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 7},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:       LinesMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		PathFilter: []string{"internal/tally/**"},
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	expected := FinalTally{
+		AuthorName:      "bob",
+		Commits:         1,
+		LinesAdded:      5,
+		FileCount:       2,
+		Churn:           5,
+		FirstCommitTime: day,
+		LastCommitTime:  day,
+	}
+	if diff := cmp.Diff(expected, bucket.Tally); diff != "" {
+		t.Errorf("bucket tally is wrong:\n%s", diff)
+	}
+	if bucket.CommitCount() != 1 {
+		t.Errorf("expected commit count of 1 but got %d", bucket.CommitCount())
+	}
+}
+
+func TestTallyCommitsByDateDecay(t *testing.T) {
+	now := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       now.Add(-2 * 30 * 24 * time.Hour),
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 100},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       now,
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 30},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:  LinesMode,
+		Key:   func(c git.Commit) string { return c.AuthorName },
+		Decay: HalfLifeDecay(30 * 24 * time.Hour),
+		AsOf:  now,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	series := TimeSeries(buckets)
+	totals := map[string]int64{}
+	for _, bucket := range series {
+		for tally := range bucket.Tallies() {
+			totals[tally.AuthorName] += tally.LinesAdded
+		}
+	}
+
+	// Two half-lives old
+	expected := map[string]int64{"bob": 25, "alice": 30}
+	if diff := cmp.Diff(expected, totals); diff != "" {
+		t.Errorf("decayed lines are wrong:\n%s", diff)
+	}
+
+	if winner := series.Winner(opts.Mode); winner.AuthorName != "alice" {
+		t.Errorf("expected alice to win but got %s", winner.AuthorName)
+	}
+}
+
+func TestTallyCommitsByDateExcludePaths(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "internal/tally/tally.go", LinesAdded: 3},
+				{Path: "internal/tally/tally.pb.go", LinesAdded: 100},
+				{Path: "main.go", LinesAdded: 10},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "internal/tally/vendor/lib.go", LinesAdded: 7},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:         LinesMode,
+		Key:          func(c git.Commit) string { return c.AuthorName },
+		PathFilter:   []string{"internal/**"},
+		ExcludePaths: []string{"*.pb.go", "vendor/"},
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.TotalTally.LinesAdded != 3 {
+		t.Errorf(
+			"expected 3 lines added but got %d",
+			bucket.TotalTally.LinesAdded,
+		)
+	}
+	if bucket.CommitCount() != 1 {
+		t.Errorf("expected commit count of 1 but got %d", bucket.CommitCount())
+	}
+}
+
+func TestTallyCommitsByDatePathWeight(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 4, LinesRemoved: 1},
+				{Path: "web/package-lock.json", LinesAdded: 900},
+				{Path: "vendor/lib/lib.go", LinesRemoved: 300},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "api/api.pb.go", LinesAdded: 500},
+				{Path: "api/api.go", LinesAdded: 2},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:       LinesMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		PathWeight: GeneratedFileWeight,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	expected := FinalTally{
+		AuthorName:      "bob",
+		Commits:         1,
+		LinesAdded:      4,
+		LinesRemoved:    1,
+		FileCount:       3,
+		Churn:           5,
+		FirstCommitTime: day,
+		LastCommitTime:  day,
+	}
+	if diff := cmp.Diff(expected, bucket.Tally); diff != "" {
+		t.Errorf("bucket tally is wrong:\n%s", diff)
+	}
+	if bucket.TotalTally.LinesAdded != 6 {
+		t.Errorf(
+			"expected 6 total lines added but got %d",
+			bucket.TotalTally.LinesAdded,
+		)
+	}
+}
+
+func TestTallyCommitsTimelineFractionalFiles(t *testing.T) {
+	monday := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			Hash:       "a",
+			AuthorName: "bob",
+			Date:       monday,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 1},
+				{Path: "b.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:       "b",
+			AuthorName: "alice",
+			Date:       monday,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:       "c",
+			AuthorName: "carol",
+			Date:       monday,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 1},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:            FilesMode,
+		Key:             func(c git.Commit) string { return c.AuthorName },
+		Resolution:      DailyResolution,
+		FractionalFiles: true,
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		monday,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	if len(buckets) != 1 {
+		t.Fatalf("expected 1 bucket but got %d", len(buckets))
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	shares := map[string]float64{}
+	for tally := range bucket.Tallies() {
+		shares[tally.AuthorName] = tally.FileShare
+	}
+
+	expected := map[string]float64{
+		"bob":   1 + 1.0/3,
+		"alice": 1.0 / 3,
+		"carol": 1.0 / 3,
+	}
+	for name, share := range expected {
+		if math.Abs(shares[name]-share) > 1e-9 {
+			t.Errorf(
+				"expected %s to have file share %f but got %f",
+				name,
+				share,
+				shares[name],
+			)
+		}
+	}
+
+	if bucket.Tally.AuthorName != "bob" {
+		t.Errorf("expected bob to win but got %s", bucket.Tally.AuthorName)
+	}
+	if math.Abs(bucket.TotalTally.FileShare-2) > 1e-9 {
+		t.Errorf(
+			"expected total file share of 2 but got %f",
+			bucket.TotalTally.FileShare,
+		)
+	}
+	if bucket.TotalTally.FileCount != 2 {
+		t.Errorf(
+			"expected 2 distinct files but got %d",
+			bucket.TotalTally.FileCount,
+		)
+	}
+}
+
+func TestTallyCommitsByDateMaxDiffLines(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 40, LinesRemoved: 10},
+				{Path: "package-lock.json", LinesAdded: 2_000_000},
+			},
+		},
+		{
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "api.go", LinesAdded: 60, LinesRemoved: 150},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:         LinesMode,
+		Key:          func(c git.Commit) string { return c.AuthorName },
+		MaxDiffLines: 100,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	type result struct {
+		Added   int64
+		Removed int64
+		Files   int
+	}
+	results := map[string]result{}
+	for tally := range buckets[0].Rank(opts.Mode).Tallies() {
+		results[tally.AuthorName] = result{
+			Added:   tally.LinesAdded,
+			Removed: tally.LinesRemoved,
+			Files:   tally.FileCount,
+		}
+	}
+
+	expected := map[string]result{
+		"bob":   {Added: 140, Removed: 10, Files: 2},
+		"alice": {Added: 60, Removed: 100, Files: 1},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("capped tallies are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsByDateTeams(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "bob", AuthorEmail: "bob@corp.com", Date: day},
+		{AuthorName: "alice", AuthorEmail: "Alice@corp.com", Date: day},
+		{AuthorName: "carol", AuthorEmail: "carol@corp.com", Date: day},
+		{AuthorName: "dan", AuthorEmail: "dan@corp.com", Date: day},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+		Teams: map[string]string{
+			"bob@corp.com":   "Platform",
+			"alice@corp.com": "Platform",
+			"carol@corp.com": "Web",
+		},
+	}
+
+	tallyNames := func(opts TallyOpts) map[string]int {
+		buckets, err := TallyCommitsByDate(
+			iterutils.WithoutErrors(slices.Values(commits)),
+			opts,
+		)
+		if err != nil {
+			t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+		}
+
+		counts := map[string]int{}
+		for tally := range buckets[0].Tallies() {
+			counts[tally.AuthorName] = tally.Commits
+		}
+		return counts
+	}
+
+	expected := map[string]int{"Platform": 2, "Web": 1, "dan": 1}
+	if diff := cmp.Diff(expected, tallyNames(opts)); diff != "" {
+		t.Errorf("team tallies are wrong:\n%s", diff)
+	}
+
+	opts.UnknownTeam = "Unknown"
+	expected = map[string]int{"Platform": 2, "Web": 1, "Unknown": 1}
+	if diff := cmp.Diff(expected, tallyNames(opts)); diff != "" {
+		t.Errorf("team tallies with unknown team are wrong:\n%s", diff)
+	}
+
+	opts.Teams = nil
+	opts.GroupBy = EmailDomainGroup
+	expected = map[string]int{"corp.com": 4}
+	if diff := cmp.Diff(expected, tallyNames(opts)); diff != "" {
+		t.Errorf("domain tallies are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsByDateProgress(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{}
+	for i := range 2*ProgressInterval + 10 {
+		commits = append(commits, git.Commit{
+			AuthorName: "bob",
+			Date:       start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	calls := []int{}
+	opts := TallyOpts{
+		Mode:     CommitMode,
+		Key:      func(c git.Commit) string { return c.AuthorName },
+		Progress: func(n int) { calls = append(calls, n) },
+	}
+
+	_, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	expected := []int{ProgressInterval, 2 * ProgressInterval}
+	if diff := cmp.Diff(expected, calls); diff != "" {
+		t.Errorf("progress calls are wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsByDateContextCancelled(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{}
+	for i := range 3 * cancelCheckInterval {
+		commits = append(commits, git.Commit{
+			Hash:       fmt.Sprintf("%x", i),
+			AuthorName: "bob",
+			Date:       start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel partway through the second block of commits
+	seq := func(yield func(git.Commit, error) bool) {
+		for i, commit := range commits {
+			if i == cancelCheckInterval+cancelCheckInterval/2 {
+				cancel()
+			}
+			if !yield(commit, nil) {
+				return
+			}
+		}
+	}
+
+	buckets, err := TallyCommitsByDateContext(ctx, seq, opts)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrIncomplete) {
+		t.Fatalf("expected cancellation error but got: %v", err)
+	}
+
+	// Commits read before the next check are still tallied
+	total := TimeSeries(buckets).Totals()
+	if total.Commits != 2*cancelCheckInterval {
+		t.Errorf(
+			"expected %d commits tallied but got %d",
+			2*cancelCheckInterval,
+			total.Commits,
+		)
+	}
+}
+
+func TestTallyCommitsByDateRetainFiles(t *testing.T) {
+	monday := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	tuesday := time.Date(2024, 4, 2, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			Hash:       "a",
+			AuthorName: "bob",
+			Date:       monday,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 1},
+				{Path: "b.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:       "b",
+			AuthorName: "bob",
+			Date:       tuesday,
+			FileDiffs: []git.FileDiff{
+				{Path: "b.go", LinesAdded: 1},
+				{Path: "c.go", LinesAdded: 1},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:        FilesMode,
+		Key:         func(c git.Commit) string { return c.AuthorName },
+		RetainFiles: true,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	first := buckets[0].Rank(opts.Mode).Tally
+	second := buckets[1].Rank(opts.Mode).Tally
+	if diff := cmp.Diff([]string{"a.go", "b.go"}, first.Files); diff != "" {
+		t.Errorf("retained files are wrong:\n%s", diff)
+	}
+
+	combined := first.Combine(second)
+	expected := []string{"a.go", "b.go", "c.go"}
+	if diff := cmp.Diff(expected, combined.Files); diff != "" {
+		t.Errorf("combined files are wrong:\n%s", diff)
+	}
+	if combined.FileCount != 3 {
+		t.Errorf("expected 3 distinct files but got %d", combined.FileCount)
+	}
+
+	opts.RetainFiles = false
+	buckets, err = TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	if files := buckets[0].Rank(opts.Mode).Tally.Files; files != nil {
+		t.Errorf("expected no retained files but got %v", files)
+	}
+}
+
+func TestTallyCommitsByDateDiffKey(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			Hash:       "a",
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "internal/git/git.go", LinesAdded: 4},
+				{Path: "internal/tally/tally.go", LinesAdded: 2},
+				{Path: "docs/intro.md", LinesAdded: 1},
+				{Path: "main.go", LinesRemoved: 3},
+			},
+		},
+		{
+			Hash:       "b",
+			AuthorName: "alice",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "docs/usage.md", LinesAdded: 5},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:    LinesMode,
+		Key:     func(c git.Commit) string { return c.AuthorName },
+		DiffKey: TopLevelDirKey,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	keys := slices.Sorted(maps.Keys(bucket.tallies))
+	if diff := cmp.Diff([]string{".", "docs", "internal"}, keys); diff != "" {
+		t.Errorf("bucket keys are wrong:\n%s", diff)
+	}
+
+	internal := bucket.tallies["internal"].Final()
+	if internal.AuthorName != "internal" || internal.LinesAdded != 6 {
+		t.Errorf("internal tally is wrong: %+v", internal)
+	}
+
+	docs := bucket.tallies["docs"].Final()
+	if docs.Commits != 2 || docs.FileCount != 2 {
+		t.Errorf("expected docs to have 2 commits and 2 files: %+v", docs)
+	}
+}
+
+func TestTallyCommitsByDateCoAuthors(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			Hash:        "a",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        day,
+			CoAuthors: []git.Author{
+				{Name: "alice", Email: "alice@mail.com"},
+				{Name: "Alice", Email: "Alice@mail.com"},
+				{Name: "bob", Email: "bob@mail.com"},
+			},
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 4},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		coAuthors bool
+		expected  []string
+	}{
+		{
+			name:      "ignored",
+			coAuthors: false,
+			expected:  []string{"bob@mail.com"},
+		},
+		{
+			name:      "credited",
+			coAuthors: true,
+			expected:  []string{"alice@mail.com", "bob@mail.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:      LinesMode,
+				Key:       func(c git.Commit) string { return c.AuthorEmail },
+				CoAuthors: test.coAuthors,
+			}
+
+			buckets, err := TallyCommitsByDate(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+			}
+
+			bucket := buckets[0]
+			keys := slices.Sorted(maps.Keys(bucket.tallies))
+			if diff := cmp.Diff(test.expected, keys); diff != "" {
+				t.Errorf("credited authors are wrong:\n%s", diff)
+			}
+
+			for _, key := range keys {
+				if added := bucket.tallies[key].added; added != 4 {
+					t.Errorf("expected %s to get 4 lines but got %d", key, added)
+				}
+			}
+		})
+	}
+}
+
+func TestTallyCommitsByDateChurn(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			AuthorName: "writer",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 10, LinesRemoved: 2},
+			},
+		},
+		{
+			AuthorName: "cleaner",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "b.go", LinesAdded: 1, LinesRemoved: 16},
+			},
+		},
+	}
+
+	half := 0.5
+	zero := 0.0
+	tests := []struct {
+		name   string
+		weight *float64
+		winner string
+		value  int64
+	}{
+		{
+			name:   "default_weight",
+			weight: nil,
+			winner: "cleaner",
+			value:  17,
+		},
+		{
+			name:   "half_weight",
+			weight: &half,
+			winner: "writer",
+			value:  11,
+		},
+		{
+			name:   "zero_weight",
+			weight: &zero,
+			winner: "writer",
+			value:  10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:        ChurnMode,
+				Key:         func(c git.Commit) string { return c.AuthorName },
+				ChurnWeight: test.weight,
+			}
+
+			buckets, err := TallyCommitsByDate(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+			}
+
+			bucket := buckets[0].Rank(opts.Mode)
+			if bucket.Tally.AuthorName != test.winner {
+				t.Errorf(
+					"expected %s to win but got %s",
+					test.winner,
+					bucket.Tally.AuthorName,
+				)
+			}
+			if bucket.Value(opts.Mode) != test.value {
+				t.Errorf(
+					"expected value of %d but got %d",
+					test.value,
+					bucket.Value(opts.Mode),
+				)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsByDateMerges(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "alice", Date: day, NumParents: 1},
+		{AuthorName: "alice", Date: day, NumParents: 1},
+		{AuthorName: "bob", Date: day, NumParents: 2, IsMerge: true},
+		{AuthorName: "bob", Date: day, NumParents: 2, IsMerge: true},
+		{AuthorName: "bob", Date: day, NumParents: 2, IsMerge: true},
+	}
+
+	tests := []struct {
+		name        string
+		countMerges bool
+		winner      string
+		total       int
+	}{
+		{
+			name:        "ignore_merges",
+			countMerges: false,
+			winner:      "alice",
+			total:       2,
+		},
+		{
+			name:        "count_merges",
+			countMerges: true,
+			winner:      "bob",
+			total:       5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:        CommitMode,
+				Key:         func(c git.Commit) string { return c.AuthorName },
+				CountMerges: test.countMerges,
+			}
+
+			buckets, err := TallyCommitsByDate(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+			}
+
+			bucket := buckets[0].Rank(opts.Mode)
+			if bucket.Tally.AuthorName != test.winner {
+				t.Errorf(
+					"expected %s to win but got %s",
+					test.winner,
+					bucket.Tally.AuthorName,
+				)
+			}
+			if bucket.TotalTally.Commits != test.total {
+				t.Errorf(
+					"expected %d commits in total but got %d",
+					test.total,
+					bucket.TotalTally.Commits,
+				)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsByDateMergeDiffs(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	diff := git.FileDiff{Path: "feature.go", LinesAdded: 10}
+
+	// alice branches off the root and bob merges her work into main
+	commits := []git.Commit{
+		{
+			Hash:       "a",
+			ShortHash:  "a",
+			AuthorName: "carol",
+			Date:       day,
+			FileDiffs:  []git.FileDiff{{Path: "main.go", LinesAdded: 1}},
+		},
+		{
+			Hash:       "b",
+			ShortHash:  "b",
+			AuthorName: "alice",
+			Date:       day,
+			NumParents: 1,
+			Parents:    []string{"a"},
+			FileDiffs:  []git.FileDiff{diff},
+		},
+		{
+			Hash:       "m",
+			ShortHash:  "m",
+			AuthorName: "bob",
+			Date:       day.Add(time.Hour),
+			NumParents: 2,
+			Parents:    []string{"a", "b"},
+			IsMerge:    true,
+			FileDiffs:  []git.FileDiff{diff},
+		},
+	}
+	opts := TallyOpts{
+		Mode:       LinesMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		MergeDiffs: true,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.Tally.AuthorName != "bob" || bucket.Tally.LinesAdded != 10 {
+		t.Errorf("expected bob to win with 10 lines but got %v", bucket.Tally)
+	}
+	if bucket.TotalTally.Commits != 2 || bucket.TotalTally.LinesAdded != 11 {
+		t.Errorf(
+			"expected feature to be counted once but got total %v",
+			bucket.TotalTally,
+		)
+	}
+	if bucket.AuthorCount() != 2 {
+		t.Errorf("expected alice's branch commit to be ignored")
+	}
+}
+
+func TestTallyCommitsByDateExcludeBots(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "dependabot[bot]", Date: day},
+		{AuthorName: "dependabot[bot]", Date: day},
+		{
+			AuthorName:  "renovate",
+			AuthorEmail: "29139614+renovate@users.noreply@github.com",
+			Date:        day,
+		},
+		{AuthorName: "alice", AuthorEmail: "alice@mail.com", Date: day},
+	}
+	opts := TallyOpts{
+		Mode:           CommitMode,
+		Key:            func(c git.Commit) string { return c.AuthorName },
+		ExcludeAuthors: DefaultBotPatterns,
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.Tally.AuthorName != "alice" {
+		t.Errorf("expected alice to win but got %s", bucket.Tally.AuthorName)
+	}
+	if bucket.TotalTally.Commits != 1 {
+		t.Errorf(
+			"expected 1 commit in total but got %d",
+			bucket.TotalTally.Commits,
+		)
+	}
+}
+
+func TestTallyCommitsTimelineCommitTimes(t *testing.T) {
+	first := time.Date(2024, 1, 3, 9, 0, 0, 0, time.Local)
+	middle := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+	last := time.Date(2024, 1, 29, 17, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "alice", Date: middle},
+		{AuthorName: "alice", Date: last},
+		{AuthorName: "bob", Date: first},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: MonthlyResolution,
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	if len(buckets) != 1 {
+		t.Fatalf("expected 1 bucket but got %d", len(buckets))
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if !bucket.Tally.FirstCommitTime.Equal(middle) {
+		t.Errorf(
+			"expected first commit time %v but got %v",
+			middle,
+			bucket.Tally.FirstCommitTime,
+		)
+	}
+	if !bucket.Tally.LastCommitTime.Equal(last) {
+		t.Errorf(
+			"expected last commit time %v but got %v",
+			last,
+			bucket.Tally.LastCommitTime,
+		)
+	}
+	if !bucket.TotalTally.FirstCommitTime.Equal(first) {
+		t.Errorf(
+			"expected total first commit time %v but got %v",
+			first,
+			bucket.TotalTally.FirstCommitTime,
+		)
+	}
+}
+
+func TestTimeBucketAuthorCount(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "Bob", AuthorEmail: "bob@mail.com", Date: day},
+		{AuthorName: "Bob Smith", AuthorEmail: "bob@mail.com", Date: day},
+		{AuthorName: "Alice", AuthorEmail: "alice@mail.com", Date: day},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	if count := buckets[0].AuthorCount(); count != 2 {
+		t.Errorf("expected author count of 2 but got %d", count)
+	}
+}
+
+func TestTallyCommitsByDateFirstCommitOnly(t *testing.T) {
+	jan := time.Date(2024, 1, 10, 9, 0, 0, 0, time.Local)
+	feb := time.Date(2024, 2, 10, 9, 0, 0, 0, time.Local)
+	mar := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
+
+	// Out of order on purpose
+	commits := []git.Commit{
+		{
+			Hash:       "c",
+			AuthorName: "bob",
+			Date:       mar,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 50},
+				{Path: "b.go", LinesAdded: 50},
+			},
+		},
+		{
+			Hash:       "b",
+			AuthorName: "bob",
+			Date:       feb,
+			FileDiffs: []git.FileDiff{
+				{Path: "b.go", LinesAdded: 3},
+			},
+		},
+		{
+			Hash:       "a",
+			AuthorName: "alice",
+			Date:       jan,
+			FileDiffs: []git.FileDiff{
+				{Path: "a.go", LinesAdded: 10},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode:            LinesMode,
+		Key:             func(c git.Commit) string { return c.AuthorName },
+		Resolution:      MonthlyResolution,
+		FirstCommitOnly: true,
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets but got %d", len(buckets))
+	}
+
+	expected := []struct {
+		winner string
+		lines  int64
+	}{
+		{winner: "alice", lines: 10},
+		{winner: "bob", lines: 3},
+		{winner: "", lines: 0}, // Didn't create any files
+	}
+	for i, exp := range expected {
+		bucket := buckets[i].Rank(opts.Mode)
+		if bucket.Tally.AuthorName != exp.winner {
+			t.Errorf(
+				"bucket %s: expected winner \"%s\" but got \"%s\"",
+				bucket.Name,
+				exp.winner,
+				bucket.Tally.AuthorName,
+			)
+		}
+		if bucket.TotalValue(opts.Mode) != exp.lines {
+			t.Errorf(
+				"bucket %s: expected %d lines but got %d",
+				bucket.Name,
+				exp.lines,
+				bucket.TotalValue(opts.Mode),
+			)
+		}
+	}
+}
+
+func TestTallyCommitsByDateRenames(t *testing.T) {
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{
+		{
+			Hash:       "a",
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "foo.go", LinesAdded: 10},
+			},
+		},
+		{
+			Hash:       "b",
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "bar.go", OldPath: "foo.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:       "c",
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{Path: "bar.go", LinesAdded: 2},
+				{Path: "baz.go", LinesAdded: 3},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode: FilesMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(opts.Mode)
+	if bucket.Tally.FileCount != 2 {
+		t.Errorf("expected 2 files but got %d", bucket.Tally.FileCount)
+	}
+
+	// Renaming shouldn't count as creating a file
+	opts.FirstCommitOnly = true
+	buckets, err = TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket = buckets[0].Rank(opts.Mode)
+	if bucket.Tally.FileCount != 2 {
+		t.Errorf(
+			"expected 2 created files but got %d",
+			bucket.Tally.FileCount,
+		)
+	}
+	if bucket.Tally.LinesAdded != 13 {
+		t.Errorf(
+			"expected 13 lines in created files but got %d",
+			bucket.Tally.LinesAdded,
+		)
+	}
+}
+
+func TestTallyCommitsByDateCommitterDate(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName:    "bob",
+			Date:          time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local),
+			CommitterDate: time.Date(2024, 4, 3, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName:    "alice",
+			Date:          time.Date(2024, 4, 2, 9, 0, 0, 0, time.Local),
+			CommitterDate: time.Date(2024, 4, 2, 9, 0, 0, 0, time.Local),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		source   DateSource
+		expected map[string]string
+	}{
+		{
+			name:   "author_date",
+			source: AuthorDate,
+			expected: map[string]string{
+				"2024-04-01": "bob",
+				"2024-04-02": "alice",
+			},
+		},
+		{
+			name:   "committer_date",
+			source: CommitterDate,
+			expected: map[string]string{
+				"2024-04-02": "alice",
+				"2024-04-03": "bob",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:       CommitMode,
+				Key:        func(c git.Commit) string { return c.AuthorName },
+				DateSource: test.source,
+			}
+
+			buckets, err := TallyCommitsByDate(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+			}
+
+			winners := map[string]string{}
+			for _, bucket := range buckets {
+				bucket = bucket.Rank(opts.Mode)
+				winners[bucket.Name] = bucket.Tally.AuthorName
+			}
+			if diff := cmp.Diff(test.expected, winners); diff != "" {
+				t.Errorf("buckets are wrong:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsTimelineAsOf(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2023, 12, 1, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 5, 20, 9, 0, 0, 0, time.Local),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		resolution ResolutionMode
+		expected   []string
+	}{
+		{
+			name:       "monthly",
+			resolution: MonthlyResolution,
+			expected: []string{
+				"Dec 2023",
+				"Jan 2024",
+				"Feb 2024",
+				"Mar 2024",
+				"Apr 2024",
+				"May 2024",
+				"Jun 2024",
+			},
+		},
+		{
+			name:       "relative",
+			resolution: RelativeResolution,
+			expected: []string{
+				"0–30 days ago",
+				"30–90 days ago",
+				"90–180 days ago",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TallyOpts{
+				Mode:       CommitMode,
+				Key:        func(c git.Commit) string { return c.AuthorName },
+				Resolution: test.resolution,
+				AsOf:       time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local),
+			}
+
+			// The end time shouldn't matter once AsOf is pinned
+			for _, end := range []time.Time{
+				time.Now(),
+				time.Now().AddDate(3, 0, 0),
+			} {
+				buckets, _, err := TallyCommitsTimeline(
+					iterutils.WithoutErrors(slices.Values(commits)),
+					opts,
+					end,
+				)
+				if err != nil {
+					t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+				}
+
+				names := []string{}
+				for _, bucket := range buckets {
+					names = append(names, bucket.Name)
+				}
+				if diff := cmp.Diff(test.expected, names); diff != "" {
+					t.Errorf("buckets are wrong:\n%s", diff)
+				}
+
+				last := buckets[len(buckets)-1]
+				if test.resolution == MonthlyResolution && !last.Partial {
+					t.Errorf("expected last bucket to be partial")
+				}
+			}
+		})
+	}
+}
+
+func TestTallyCommitsTimelineWindow(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2023, 12, 25, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 1, 3, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 2, 12, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: WeeklyResolution,
+		Since:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		Until:      time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local),
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	names := []string{}
+	total := 0
+	for _, bucket := range buckets {
+		names = append(names, bucket.Name)
+		total += bucket.Rank(opts.Mode).TotalTally.Commits
+	}
+
+	expectedNames := []string{
+		"2024-W01",
+		"2024-W02",
+		"2024-W03",
+		"2024-W04",
+		"2024-W05",
+	}
+	if !slices.Equal(names, expectedNames) {
+		t.Errorf("expected buckets %v but got %v", expectedNames, names)
+	}
+
+	if total != 1 {
+		t.Errorf("expected 1 commit inside window but got %d", total)
+	}
+}
+
+func TestTimeBucketRankTiebreak(t *testing.T) {
+	bucket := TimeBucket{
+		Name: "2024-04-01",
+		Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		tallies: map[string]Tally{
+			"carol": {name: "carol", email: "carol@mail.com", numTallied: 2},
+			"alice": {name: "alice", email: "alice@mail.com", numTallied: 2},
+			"bob":   {name: "bob", email: "bob@mail.com", numTallied: 2},
+		},
+	}
+
+	// Map iteration order is random, so try a few times
+	for range 20 {
+		ranked := bucket.Rank(CommitMode)
+		if ranked.Tally.AuthorEmail != "alice@mail.com" {
+			t.Fatalf(
+				"expected alice to win tie but got %s",
+				ranked.Tally.AuthorEmail,
+			)
+		}
+	}
+}
+
+func TestTimeBucketRankFilesModeNoFiles(t *testing.T) {
+	// Bob only made empty commits and submodule bumps
+	bob := Tally{name: "bob", commitset: map[string]bool{"a": true, "b": true}}
+	alice := Tally{
+		name:      "alice",
+		commitset: map[string]bool{"c": true},
+		fileset:   map[string]bool{"foo.go": true},
+	}
+
+	bucket := TimeBucket{
+		tallies: map[string]Tally{"bob": bob, "alice": alice},
+	}.Rank(FilesMode)
+	if bucket.Tally.AuthorName != "alice" {
+		t.Errorf("expected alice to win but got %s", bucket.Tally.AuthorName)
+	}
+	if bucket.RunnerUp.AuthorName != "" {
+		t.Errorf(
+			"expected no runner-up but got %s",
+			bucket.RunnerUp.AuthorName,
+		)
+	}
+
+	bucket = TimeBucket{tallies: map[string]Tally{"bob": bob}}.Rank(FilesMode)
+	if bucket.Tally.AuthorName != "" {
+		t.Errorf("expected no winner but got %s", bucket.Tally.AuthorName)
+	}
+	if bucket.TotalTally.Commits != 2 {
+		t.Errorf(
+			"expected 2 total commits but got %d",
+			bucket.TotalTally.Commits,
+		)
+	}
+
+	// Commits still count in CommitMode
+	bucket = TimeBucket{tallies: map[string]Tally{"bob": bob}}.Rank(CommitMode)
+	if bucket.Tally.AuthorName != "bob" {
+		t.Errorf("expected bob to win but got %s", bucket.Tally.AuthorName)
+	}
+}
+
+func TestTimeBucketRankExcluding(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 5},
+			"bob":   {name: "bob", numTallied: 2},
+			"carol": {name: "carol", numTallied: 1},
+		},
+	}
+
+	ranked := bucket.RankExcluding(CommitMode, map[string]bool{"alice": true})
+	if ranked.Tally.AuthorName != "bob" {
+		t.Errorf("expected bob to win but got %s", ranked.Tally.AuthorName)
+	}
+	if ranked.RunnerUp.AuthorName != "carol" {
+		t.Errorf(
+			"expected carol to be runner-up but got %s",
+			ranked.RunnerUp.AuthorName,
+		)
+	}
+	if ranked.TotalTally.Commits != 8 {
+		t.Errorf(
+			"expected 8 total commits but got %d",
+			ranked.TotalTally.Commits,
+		)
+	}
+
+	ranked = bucket.RankExcluding(
+		CommitMode,
+		map[string]bool{"alice": true, "bob": true, "carol": true},
+	)
+	if ranked.Tally.AuthorName != "" {
+		t.Errorf("expected no winner but got %s", ranked.Tally.AuthorName)
+	}
+}
+
+func TestTimeBucketWinnerShare(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 3},
+			"bob":   {name: "bob", numTallied: 1},
+		},
+	}.Rank(CommitMode)
+
+	if share := bucket.WinnerShare(CommitMode); share != 0.75 {
+		t.Errorf("expected winner share of 0.75 but got %f", share)
+	}
+
+	empty := newBucket("2024-04-01", time.Now()).Rank(CommitMode)
+	if share := empty.WinnerShare(CommitMode); share != 0 {
+		t.Errorf("expected winner share of 0 for empty bucket but got %f", share)
+	}
+}
+
+func TestTimeBucketTopN(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 3},
+			"bob":   {name: "bob", numTallied: 1},
+			"carol": {name: "carol", numTallied: 2},
+			"dave":  {name: "dave", numTallied: 2},
+		},
+	}
+
+	names := func(tallies []FinalTally) []string {
+		ret := []string{}
+		for _, t := range tallies {
+			ret = append(ret, t.AuthorName)
+		}
+		return ret
+	}
+
+	top := names(bucket.TopN(3, CommitMode))
+	expected := []string{"alice", "carol", "dave"}
+	if diff := cmp.Diff(expected, top); diff != "" {
+		t.Errorf("top 3 authors are wrong:\n%s", diff)
+	}
+
+	all := names(bucket.TopN(10, CommitMode))
+	expected = []string{"alice", "carol", "dave", "bob"}
+	if diff := cmp.Diff(expected, all); diff != "" {
+		t.Errorf("expected all authors when n is too big:\n%s", diff)
+	}
+}
+
+func TestTimeBucketPrune(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 3},
+			"bob":   {name: "bob", numTallied: 1},
+			"carol": {name: "carol", numTallied: 2},
+			"dave":  {name: "dave", numTallied: 2},
+		},
+	}
+
+	pruned := bucket.Rank(CommitMode).Prune(2, CommitMode)
+	keys := slices.Sorted(maps.Keys(pruned.tallies))
+	expected := []string{OthersKey, "alice", "carol"}
+	if diff := cmp.Diff(expected, keys); diff != "" {
+		t.Errorf("pruned authors are wrong:\n%s", diff)
+	}
+
+	others := pruned.tallies[OthersKey].Final()
+	if others.AuthorName != "Others" || others.Commits != 3 {
+		t.Errorf("expected Others to have 3 commits but got %+v", others)
+	}
+	if pruned.Tally.AuthorName != "alice" {
+		t.Errorf("expected alice to win but got %s", pruned.Tally.AuthorName)
+	}
+	if pruned.TotalTally.Commits != 8 {
+		t.Errorf(
+			"expected 8 commits in total but got %d",
+			pruned.TotalTally.Commits,
+		)
+	}
+	if len(bucket.tallies) != 4 {
+		t.Errorf("pruning modified the original bucket")
+	}
+
+	if unpruned := bucket.Prune(4, CommitMode); len(unpruned.tallies) != 4 {
+		t.Errorf("expected no pruning when n covers every author")
+	}
+}
+
+func TestTimeBucketFoldBelow(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 4},
+			"bob":   {name: "bob", numTallied: 2},
+			"carol": {name: "carol", numTallied: 3},
+			"dave":  {name: "dave", numTallied: 1},
+		},
+	}
+
+	// Exactly 20% of the commits is enough to stay out of Others
+	folded := bucket.Rank(CommitMode).FoldBelow(0.2, CommitMode)
+	keys := slices.Sorted(maps.Keys(folded.tallies))
+	expected := []string{OthersKey, "alice", "bob", "carol"}
+	if diff := cmp.Diff(expected, keys); diff != "" {
+		t.Errorf("folded authors are wrong:\n%s", diff)
+	}
+
+	others := folded.tallies[OthersKey].Final()
+	if others.AuthorName != "Others" || others.Commits != 1 {
+		t.Errorf("expected Others to have 1 commit but got %+v", others)
+	}
+	if folded.TotalTally.Commits != 10 {
+		t.Errorf(
+			"expected 10 commits in total but got %d",
+			folded.TotalTally.Commits,
+		)
+	}
+	if len(bucket.tallies) != 4 {
+		t.Errorf("folding modified the original bucket")
+	}
+
+	unfolded := bucket.Rank(CommitMode).FoldBelow(0.1, CommitMode)
+	if _, ok := unfolded.tallies[OthersKey]; ok {
+		t.Errorf("expected no Others when every author meets the share")
+	}
+}
+
+func TestTimeBucketWinnerMargin(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 5},
+			"bob":   {name: "bob", numTallied: 2},
+			"carol": {name: "carol", numTallied: 4},
+		},
+	}
+
+	ranked := bucket.Rank(CommitMode)
+	if ranked.RunnerUp.AuthorName != "carol" {
+		t.Errorf(
+			"expected carol as runner-up but got %s",
+			ranked.RunnerUp.AuthorName,
+		)
+	}
+	if margin := ranked.WinnerMargin(CommitMode); margin != 1 {
+		t.Errorf("expected margin of 1 but got %d", margin)
+	}
+
+	solo := TimeBucket{
+		tallies: map[string]Tally{"alice": {name: "alice", numTallied: 5}},
+	}
+	if margin := solo.Rank(CommitMode).WinnerMargin(CommitMode); margin != 0 {
+		t.Errorf("expected margin of 0 with one author but got %d", margin)
+	}
+
+	if margin := (TimeBucket{}).WinnerMargin(CommitMode); margin != 0 {
+		t.Errorf("expected margin of 0 for empty bucket but got %d", margin)
+	}
+}
+
+func TestTimeSeriesTenures(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC)
+	}
+	alice := Tally{name: "alice", email: "alice@mail.com", numTallied: 1}
+	bob := Tally{name: "bob", email: "bob@mail.com", numTallied: 1}
+
+	series := TimeSeries{
+		{Time: day(1), tallies: map[string]Tally{"alice": alice}},
+		{
+			Time: day(2),
+			tallies: map[string]Tally{
+				"bob":     bob,
+				OthersKey: newOthersTally(),
+			},
+		},
+		{Time: day(3), tallies: map[string]Tally{}},
+		{Time: day(4), tallies: map[string]Tally{"alice": alice}},
+	}
+
+	expected := []Tenure{
+		{
+			AuthorName:    "alice",
+			AuthorEmail:   "alice@mail.com",
+			First:         day(1),
+			Last:          day(4),
+			ActiveBuckets: 2,
+		},
+		{
+			AuthorName:    "bob",
+			AuthorEmail:   "bob@mail.com",
+			First:         day(2),
+			Last:          day(2),
+			ActiveBuckets: 1,
+		},
+	}
+	if diff := cmp.Diff(expected, series.Tenures()); diff != "" {
+		t.Errorf("tenures are wrong:\n%s", diff)
+	}
+
+	if tenures := (TimeSeries{}).Tenures(); len(tenures) != 0 {
+		t.Errorf("expected no tenures for empty series but got %v", tenures)
+	}
+}
+
+func TestTimeSeriesDiff(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC)
+	}
+	bucket := func(d int, tallies map[string]Tally) TimeBucket {
+		b := newBucket(day(d).Format(time.DateOnly), day(d))
+		b.tallies = tallies
+		return b.Rank(CommitMode)
+	}
+
+	before := TimeSeries{
+		bucket(1, map[string]Tally{
+			"alice": {name: "alice", numTallied: 3},
+			"bob":   {name: "bob", numTallied: 1},
+		}),
+		bucket(2, map[string]Tally{"bob": {name: "bob", numTallied: 2}}),
+	}
+	after := TimeSeries{
+		bucket(3, map[string]Tally{"carol": {name: "carol", numTallied: 1}}),
+		bucket(1, map[string]Tally{
+			"alice": {name: "alice", numTallied: 1},
+			"bob":   {name: "bob", numTallied: 4},
+		}),
+	}
+
+	type result struct {
+		Name          string
+		InSeries      bool
+		InOther       bool
+		Winner        string
+		OtherWinner   string
+		Delta         int64
+		WinnerChanged bool
+	}
+	results := []result{}
+	for _, diff := range before.Diff(after, CommitMode) {
+		results = append(results, result{
+			Name:          diff.Name,
+			InSeries:      diff.InSeries,
+			InOther:       diff.InOther,
+			Winner:        diff.Winner.AuthorName,
+			OtherWinner:   diff.OtherWinner.AuthorName,
+			Delta:         diff.Delta,
+			WinnerChanged: diff.WinnerChanged,
+		})
+	}
+
+	expected := []result{
+		{"2024-04-01", true, true, "alice", "bob", 1, true},
+		{"2024-04-02", true, false, "bob", "", -2, true},
+		{"2024-04-03", false, true, "", "carol", 1, true},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("diffs are wrong:\n%s", diff)
+	}
+
+	same := before.Diff(before, CommitMode)
+	for _, diff := range same {
+		if diff.Delta != 0 || diff.WinnerChanged {
+			t.Errorf("expected no change in %s but got %+v", diff.Name, diff)
+		}
+	}
+}
+
+func TestTimeSeriesPeakAndTrough(t *testing.T) {
+	bucket := func(month time.Month, commits int) TimeBucket {
+		b := newBucket(
+			fmt.Sprintf("%d", month),
+			time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC),
+		)
+		if commits > 0 {
+			b.tallies["bob"] = Tally{name: "bob", numTallied: commits}
+		}
+		return b.Rank(CommitMode)
+	}
+
+	series := TimeSeries{
+		bucket(time.January, 2),
+		bucket(time.February, 0),
+		bucket(time.March, 5),
+		bucket(time.April, 2),
+		bucket(time.May, 5),
+	}
+
+	peak, ok := series.Peak(CommitMode)
+	if !ok || peak.Time.Month() != time.March {
+		t.Errorf("expected March as peak but got %s (ok: %v)", peak.Name, ok)
+	}
+
+	trough, ok := series.Trough(CommitMode)
+	if !ok || trough.Time.Month() != time.January {
+		t.Errorf(
+			"expected January as trough but got %s (ok: %v)",
+			trough.Name,
+			ok,
+		)
+	}
+
+	empty := TimeSeries{bucket(time.January, 0)}
+	if _, ok := empty.Peak(CommitMode); ok {
+		t.Errorf("expected no peak for a series with no commits")
+	}
+	if _, ok := empty.Trough(CommitMode); ok {
+		t.Errorf("expected no trough for a series with no commits")
+	}
+}
+
+func TestTimeBucketLines(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 1, added: 10, removed: 2},
+			"bob":   {name: "bob", numTallied: 1, added: 3, removed: 4},
+		},
+	}.Rank(LinesMode)
+
+	added, removed := bucket.WinnerLines()
+	if added != 10 || removed != 2 {
+		t.Errorf("expected winner lines 10 / 2 but got %d / %d", added, removed)
+	}
+
+	added, removed = bucket.TotalLines()
+	if added != 13 || removed != 6 {
+		t.Errorf("expected total lines 13 / 6 but got %d / %d", added, removed)
+	}
+}
+
+func TestTallyCommitsByDateHugeDiffs(t *testing.T) {
+	// Each diff fits in 32 bits but their sums don't
+	day := time.Date(2024, 4, 1, 9, 0, 0, 0, time.Local)
+	commits := []git.Commit{}
+	for i := range 4 {
+		commits = append(commits, git.Commit{
+			ShortHash:  fmt.Sprintf("%d", i),
+			AuthorName: "bob",
+			Date:       day,
+			FileDiffs: []git.FileDiff{
+				{
+					Path:         "generated.go",
+					LinesAdded:   math.MaxInt32,
+					LinesRemoved: math.MaxInt32,
+				},
+			},
+		})
+	}
+	opts := TallyOpts{
+		Mode: LinesMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	bucket := buckets[0].Rank(LinesMode)
+	expected := int64(8 * math.MaxInt32)
+	if value := bucket.Value(LinesMode); value != expected {
+		t.Errorf("expected value %d but got %d", expected, value)
+	}
+	if value := bucket.TotalValue(LinesMode); value != expected {
+		t.Errorf("expected total value %d but got %d", expected, value)
+	}
+	if value := bucket.Value(NetLinesMode); value != 0 {
+		t.Errorf("expected net value 0 but got %d", value)
+	}
+}
+
+func TestTimeBucketChurnRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		added    int64
+		removed  int64
+		expected float64
+	}{
+		{"more_added", 8, 2, 0.25},
+		{"more_removed", 2, 8, 4},
+		{"only_removed", 0, 5, math.Inf(1)},
+		{"no_lines", 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bucket := TimeBucket{
+				tallies: map[string]Tally{
+					"alice": {
+						name:       "alice",
+						numTallied: 1,
+						added:      test.added,
+						removed:    test.removed,
+					},
+				},
+			}.Rank(LinesMode)
+
+			if ratio := bucket.ChurnRatio(); ratio != test.expected {
+				t.Errorf(
+					"expected churn ratio %g but got %g",
+					test.expected,
+					ratio,
+				)
+			}
+		})
+	}
+}
+
+func TestTimeBucketGini(t *testing.T) {
+	tests := []struct {
+		name     string
+		commits  []int
+		expected float64
+	}{
+		{"even", []int{5, 5, 5}, 0},
+		{"one_does_everything", []int{0, 0, 0, 10}, 0.75},
+		{"uneven", []int{1, 3}, 0.25},
+		{"single_author", []int{7}, 0},
+		{"empty", []int{}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tallies := map[string]Tally{
+				OthersKey: {name: "Others", numTallied: 100},
+			}
+			for i, commits := range test.commits {
+				name := fmt.Sprintf("author%d", i)
+				tallies[name] = Tally{name: name, numTallied: commits}
+			}
+
+			bucket := TimeBucket{tallies: tallies}
+			gini := bucket.Gini(CommitMode)
+			if math.Abs(gini-test.expected) > 1e-9 {
+				t.Errorf("expected Gini of %g but got %g", test.expected, gini)
+			}
+		})
+	}
+}
+
+func TestTimeSeriesDropMinorAuthors(t *testing.T) {
+	series := TimeSeries{
+		{
+			tallies: map[string]Tally{
+				"alice": {name: "alice", numTallied: 1},
+				"bob":   {name: "bob", numTallied: 2},
+			},
+		},
+		{
+			tallies: map[string]Tally{
+				"alice": {name: "alice", numTallied: 2},
+				"carol": {name: "carol", numTallied: 1},
+			},
+		},
+	}
+	for i, bucket := range series {
+		series[i] = bucket.Rank(CommitMode)
+	}
+
+	// Bob and Carol have fewer than 3 commits over the series
+	dropped := series.DropMinorAuthors(3, false, CommitMode)
+	for i, bucket := range dropped {
+		keys := slices.Sorted(maps.Keys(bucket.tallies))
+		if diff := cmp.Diff([]string{"alice"}, keys); diff != "" {
+			t.Errorf("authors in bucket %d are wrong:\n%s", i, diff)
+		}
+		if bucket.Tally.AuthorName != "alice" {
+			t.Errorf(
+				"expected alice to win bucket %d but got %s",
+				i,
+				bucket.Tally.AuthorName,
+			)
+		}
+	}
+	if dropped[0].TotalTally.Commits != 3 {
+		t.Errorf(
+			"expected total of 3 commits but got %d",
+			dropped[0].TotalTally.Commits,
+		)
+	}
+
+	folded := series.DropMinorAuthors(3, true, CommitMode)
+	others := folded[0].tallies[OthersKey].Final()
+	if others.AuthorName != "Others" || others.Commits != 2 {
+		t.Errorf("expected Others to have 2 commits but got %+v", others)
+	}
+	if folded[0].Tally.AuthorName != "alice" {
+		t.Errorf("expected alice to win but got %s", folded[0].Tally.AuthorName)
+	}
+
+	if len(series[0].tallies) != 2 {
+		t.Errorf("dropping authors modified the original series")
+	}
+}
+
+func TestTimeBucketRankFunc(t *testing.T) {
+	recent := time.Date(2024, 4, 20, 0, 0, 0, 0, time.Local)
+	old := time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"alice": {name: "alice", numTallied: 5, lastCommitTime: old},
+			"bob":   {name: "bob", numTallied: 1, lastCommitTime: recent},
+			"carol": {name: "carol", numTallied: 2, lastCommitTime: recent},
+		},
+	}
+
+	// Most recent first, ignoring commits
+	byRecency := func(a, b FinalTally) int {
+		return a.LastCommitTime.Compare(b.LastCommitTime)
+	}
+
+	ranked := bucket.RankFunc(byRecency)
+	if ranked.Tally.AuthorName != "bob" {
+		t.Errorf(
+			"expected bob to win on recency and name but got %s",
+			ranked.Tally.AuthorName,
+		)
+	}
+	if ranked.TotalTally.Commits != 8 {
+		t.Errorf(
+			"expected 8 commits in total but got %d",
+			ranked.TotalTally.Commits,
+		)
+	}
+
+	top := bucket.TopNFunc(2, byRecency)
+	if len(top) != 2 || top[1].AuthorName != "carol" {
+		t.Errorf("expected carol to come second but got %v", top)
+	}
+}
+
+func TestTimeBucketDebug(t *testing.T) {
+	when := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	bucket := newBucket("2024-04-01", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	bucket.tallies["bob"] = Tally{
+		name:            "bob",
+		email:           "bob@mail.com",
+		numTallied:      1,
+		added:           4,
+		churn:           4,
+		fileset:         map[string]bool{"a.go": true},
+		firstCommitTime: when,
+		lastCommitTime:  when,
+	}
+	bucket.tallies["alice"] = Tally{
+		name:            "alice",
+		numTallied:      2,
+		fileset:         map[string]bool{"a.go": true, "b.go": true},
+		firstCommitTime: when,
+		lastCommitTime:  when,
+	}
+
+	expected := "2024-04-01 (2024-04-01T00:00:00Z)\n" +
+		"  alice: name=\"alice\" email=\"\" commits=2 added=0 removed=0 " +
+		"files=2 churn=0 first=2024-04-01T09:00:00Z last=2024-04-01T09:00:00Z\n" +
+		"  bob: name=\"bob\" email=\"bob@mail.com\" commits=1 added=4 removed=0 " +
+		"files=1 churn=4 first=2024-04-01T09:00:00Z last=2024-04-01T09:00:00Z\n"
+	if dump := bucket.Debug(); dump != expected {
+		t.Errorf("expected dump:\n%s\nbut got:\n%s", expected, dump)
+	}
+}
+
+func TestTimeSeriesTrim(t *testing.T) {
+	bucket := func(day int, commits int) TimeBucket {
+		b := newBucket(
+			fmt.Sprintf("2024-04-%02d", day),
+			time.Date(2024, 4, day, 0, 0, 0, 0, time.Local),
+		)
+		if commits > 0 {
+			b.tallies["bob"] = Tally{name: "bob", numTallied: commits}
+		}
+		return b
+	}
+
+	series := TimeSeries{
+		bucket(1, 0),
+		bucket(2, 0),
+		bucket(3, 2),
+		bucket(4, 0),
+		bucket(5, 1),
+		bucket(6, 0),
+	}
+
+	trimmed := series.Trim()
+	names := []string{}
+	for _, b := range trimmed {
+		names = append(names, b.Name)
+	}
+
+	expected := []string{"2024-04-03", "2024-04-04", "2024-04-05"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("trimmed series is wrong:\n%s", diff)
+	}
+
+	empty := TimeSeries{bucket(1, 0), bucket(2, 0)}.Trim()
+	if len(empty) != 0 {
+		t.Errorf("expected all-empty series to trim to nothing but got %v", empty)
+	}
+}
+
+func TestTimeSeriesFillGaps(t *testing.T) {
+	monthly := monthlyIn(time.Local)
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	apr := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+
+	aprBucket := newBucket(monthly.label(apr), apr)
+	aprBucket.tallies["bob"] = Tally{name: "bob", numTallied: 1}
+
+	series := TimeSeries{aprBucket, newBucket(monthly.label(jan), jan)}
+	filled, err := series.FillGaps(monthly)
+	if err != nil {
+		t.Fatalf("FillGaps() returned error: %v", err)
+	}
+
+	names := []string{}
+	for _, b := range filled {
+		names = append(names, b.Name)
+	}
+
+	expected := []string{"Jan 2024", "Feb 2024", "Mar 2024", "Apr 2024"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("filled series is wrong:\n%s", diff)
+	}
+	if filled[3].CommitCount() != 1 {
+		t.Errorf("expected existing bucket to keep its tallies")
+	}
+
+	mid := time.Date(2024, 2, 15, 0, 0, 0, 0, time.Local)
+	_, err = TimeSeries{newBucket("2024-02-15", mid)}.FillGaps(monthly)
+	if err == nil {
+		t.Errorf("expected error for bucket not matching resolution")
+	}
+}
+
+func TestTimeSeriesRebucket(t *testing.T) {
+	daily := dailyIn(time.Local)
+	days := []time.Time{
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 1, 20, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local),
+	}
+
+	series := TimeSeries{}
+	for i, day := range days {
+		bucket := newBucket(daily.label(day), day)
+		bucket.tallies["bob"] = Tally{
+			name:       "bob",
+			added:      int64(i + 1),
+			numTallied: 1,
+		}
+		series = append(series, bucket)
+	}
+
+	monthly := monthlyIn(time.Local)
+	rebucketed := series.Rebucket(monthly)
+
+	names := []string{}
+	for _, b := range rebucketed {
+		names = append(names, b.Name)
+	}
+
+	expected := []string{"Jan 2024", "Feb 2024", "Mar 2024"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("rebucketed series is wrong:\n%s", diff)
+	}
+
+	jan := rebucketed[0].Rank(LinesMode)
+	if jan.Tally.Commits != 2 || jan.Tally.LinesAdded != 3 {
+		t.Errorf(
+			"expected January to combine two buckets but got %+v",
+			jan.Tally,
+		)
+	}
+	if series[0].tallies["bob"].added != 1 {
+		t.Errorf("rebucketing modified the original series")
+	}
+}
+
+func TestTimeSeriesTotals(t *testing.T) {
+	commits := []git.Commit{
+		{
+			Hash:        "a",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local),
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 4},
+			},
+		},
+		{
+			Hash:        "b",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 1, 2, 9, 0, 0, 0, time.Local),
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesAdded: 1},
+				{Path: "git.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:        "c",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 1, 3, 9, 0, 0, 0, time.Local),
+			FileDiffs: []git.FileDiff{
+				{Path: "main.go", LinesRemoved: 1},
+			},
+		},
+	}
+	opts := TallyOpts{
+		Mode: LinesMode,
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	buckets, err := TallyCommitsByDate(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsByDate() returned error: %v", err)
+	}
+
+	series := TimeSeries(buckets)
+	totals := series.Totals()
+	expected := FinalTally{
+		Commits:         3,
+		LinesAdded:      6,
+		LinesRemoved:    1,
+		FileCount:       2,
+		Churn:           7,
+		FirstCommitTime: commits[0].Date,
+		LastCommitTime:  commits[2].Date,
+	}
+	if diff := cmp.Diff(expected, totals); diff != "" {
+		t.Errorf("totals are wrong:\n%s", diff)
+	}
+
+	if winner := series.Winner(LinesMode); winner.AuthorName != "bob" {
+		t.Errorf("expected bob to win by lines but got %s", winner.AuthorName)
+	}
+	if winner := series.Winner(CommitMode); winner.AuthorName != "alice" {
+		t.Errorf("expected alice to win by commits but got %s", winner.AuthorName)
+	}
+
+	if diff := cmp.Diff(FinalTally{}, TimeSeries{}.Totals()); diff != "" {
+		t.Errorf("expected zero totals for an empty series:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsTimelineOutOfOrder(t *testing.T) {
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2024, 4, 20, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 4, 2, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "alice",
+			Date:       time.Date(2024, 4, 9, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode:       CommitMode,
+		Key:        func(c git.Commit) string { return c.AuthorName },
+		Resolution: WeeklyResolution,
+	}
+
+	buckets, _, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	winners := []string{}
+	for _, bucket := range buckets {
+		winners = append(winners, bucket.Rank(opts.Mode).Tally.AuthorName)
+	}
+
+	expected := []string{"alice", "alice", "bob"}
+	if !slices.Equal(winners, expected) {
+		t.Errorf("expected winners %v but got %v", expected, winners)
+	}
+}
+
+func TestRebucketUnsorted(t *testing.T) {
+	buckets := []TimeBucket{
+		{
+			Name:    "2024-05-02",
+			Time:    time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local),
+			tallies: map[string]Tally{"bob": {name: "bob", numTallied: 1}},
+		},
+		{
+			Name:    "2024-04-02",
+			Time:    time.Date(2024, 4, 2, 0, 0, 0, 0, time.Local),
+			tallies: map[string]Tally{"alice": {name: "alice", numTallied: 1}},
+		},
+	}
+
+	// Start and end are narrower than the buckets given
+	start := time.Date(2024, 4, 15, 0, 0, 0, 0, time.Local)
+	rebuckets := Rebucket(buckets, monthlyIn(time.Local), start, start)
+	if len(rebuckets) != 2 {
+		t.Fatalf("expected 2 buckets but got %d", len(rebuckets))
+	}
+
+	if _, ok := rebuckets[0].tallies["alice"]; !ok {
+		t.Errorf("expected alice in April bucket")
+	}
+	if _, ok := rebuckets[1].tallies["bob"]; !ok {
+		t.Errorf("expected bob in May bucket")
+	}
+}
+
+func TestRebucketMissingBucket(t *testing.T) {
+	daily := dailyIn(time.UTC)
+
+	// A resolution whose next() skips a day that apply() can produce
+	resolution := Resolution{
+		apply: daily.apply,
+		next: func(t time.Time) time.Time {
+			return daily.apply(t).AddDate(0, 0, 2)
+		},
+		label: daily.label,
+	}
+
+	buckets := []TimeBucket{
+		{
+			Time:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{"bob": {name: "bob", numTallied: 1}},
+		},
+		{
+			Time:    time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{"alice": {name: "alice", numTallied: 1}},
+		},
+	}
+
+	start := buckets[0].Time
+	rebuckets := Rebucket(buckets, resolution, start, start)
+	if len(rebuckets) != 2 {
+		t.Fatalf("expected 2 buckets but got %d", len(rebuckets))
+	}
+
+	// May 4th has no bucket of its own, so goes in the nearest (May 3rd)
+	expected := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+	if !rebuckets[1].Time.Equal(expected) {
+		t.Errorf(
+			"expected second bucket at %v but got %v",
+			expected,
+			rebuckets[1].Time,
+		)
+	}
+	if _, ok := rebuckets[1].tallies["alice"]; !ok {
+		t.Errorf("expected alice in nearest bucket")
+	}
+}
+
+func TestTimeBucketTallies(t *testing.T) {
+	bucket := TimeBucket{
+		tallies: map[string]Tally{
+			"bob":   {name: "bob", numTallied: 3},
+			"alice": {name: "alice", numTallied: 1},
+		},
+	}
+
+	names := []string{}
+	for tally := range bucket.Tallies() {
+		names = append(names, tally.AuthorName)
+	}
+	if !slices.Equal(names, []string{"alice", "bob"}) {
+		t.Errorf("expected tallies for alice and bob but got %v", names)
+	}
+}
+
+func TestTimeSeriesSortedByValue(t *testing.T) {
+	series := TimeSeries{}
+	for i, commits := range []int{3, 0, 6, 3} {
+		bucket := TimeBucket{
+			Name:  fmt.Sprintf("%d", i),
+			Time:  time.Date(2024, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC),
+			Tally: FinalTally{Commits: commits},
+		}
+		series = append(series, bucket)
+	}
+
+	names := []string{}
+	for _, bucket := range series.SortedByValue(CommitMode) {
+		names = append(names, bucket.Name)
+	}
+
+	expected := []string{"2", "0", "3", "1"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("expected buckets in order %v but got %v", expected, names)
+	}
+
+	if series[0].Name != "0" {
+		t.Errorf("expected original series to be unchanged")
+	}
+}
+
+func TestTimeSeriesMovingAverage(t *testing.T) {
+	series := TimeSeries{}
+	for _, commits := range []int{3, 0, 6, 3} {
+		bucket := TimeBucket{Tally: FinalTally{Commits: commits}}
+		series = append(series, bucket)
+	}
+
+	averages := series.MovingAverage(2, CommitMode)
+	expected := []float64{3, 1.5, 3, 4.5}
+	if !slices.Equal(averages, expected) {
+		t.Errorf("expected averages %v but got %v", expected, averages)
+	}
+}
+
+func TestTallyCommitsTimelineZeroEnd(t *testing.T) {
+	// A history that stopped years ago
+	commits := []git.Commit{
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2019, 1, 7, 9, 0, 0, 0, time.Local),
+		},
+		{
+			AuthorName: "bob",
+			Date:       time.Date(2019, 2, 4, 9, 0, 0, 0, time.Local),
+		},
+	}
+	opts := TallyOpts{
+		Mode: CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	buckets, mode, err := TallyCommitsTimeline(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+		time.Time{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+	}
+
+	// Picked from the span of the commits, not from the span up to now
+	if mode != DailyResolution {
+		t.Errorf("expected daily resolution but got %s", mode)
+	}
+
+	last := buckets[len(buckets)-1]
+	if last.Name != "2019-02-04" {
+		t.Errorf("expected timeline to end on 2019-02-04 but got %s", last.Name)
+	}
+	if last.Partial {
+		t.Errorf("expected last bucket not to be partial")
+	}
+}
+
+func TestTallyCommitsTimelineSingleCommit(t *testing.T) {
+	commitTime := time.Date(2024, 4, 2, 9, 30, 0, 0, time.Local)
+	commits := []git.Commit{
+		{AuthorName: "bob", Date: commitTime},
+	}
+
+	tests := []struct {
+		name string
+		opts TallyOpts
+		end  time.Time
+	}{
+		{"zero_end", TallyOpts{}, time.Time{}},
+		{"same_end", TallyOpts{}, commitTime},
+		{
+			"narrow_window",
+			TallyOpts{
+				Since: commitTime.Add(-time.Minute),
+				Until: commitTime.Add(time.Minute),
+			},
+			time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.Mode = CommitMode
+			opts.Key = func(c git.Commit) string { return c.AuthorName }
+
+			buckets, _, err := TallyCommitsTimeline(
+				iterutils.WithoutErrors(slices.Values(commits)),
+				opts,
+				test.end,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsTimeline() returned error: %v", err)
+			}
+
+			if len(buckets) != 1 {
+				t.Fatalf("expected 1 bucket but got %d", len(buckets))
+			}
+
+			bucket := buckets[0].Rank(opts.Mode)
+			if bucket.Tally.AuthorName != "bob" {
+				t.Errorf("expected bob to win the only bucket")
+			}
+		})
+	}
+}
+
+func TestTimeSeriesAnonymize(t *testing.T) {
+	series := TimeSeries{
+		TimeBucket{
+			Name: "2024-04-02",
+			Time: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"bob": {
+					name:       "bob",
+					email:      "bob@mail.com",
+					commitset:  map[string]bool{"c": true, "d": true},
+					numTallied: 2,
+				},
+				"alice": {
+					name:       "alice",
+					email:      "alice@mail.com",
+					commitset:  map[string]bool{"e": true},
+					numTallied: 1,
+				},
+			},
+		}.Rank(CommitMode),
+		TimeBucket{
+			Name: "2024-04-01",
+			Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"carol": {
+					name:       "carol",
+					email:      "carol@mail.com",
+					commitset:  map[string]bool{"a": true},
+					numTallied: 1,
+				},
+				OthersKey: {
+					name:       "Others",
+					commitset:  map[string]bool{"b": true},
+					numTallied: 1,
+				},
+			},
+		}.Rank(CommitMode),
+	}
+
+	anonymized := series.Anonymize()
+
+	if series[0].Tally.AuthorName != "bob" {
+		t.Errorf("expected original series to be unchanged")
+	}
+
+	// Carol appears first chronologically, then alice before bob by key
+	bucket := anonymized[0]
+	if bucket.Tally.AuthorName != "Author 3" ||
+		bucket.Tally.AuthorEmail != "author3@anonymous.invalid" ||
+		bucket.Tally.Commits != 2 {
+		t.Errorf(
+			"expected winner to be Author 3 with 2 commits but got %v",
+			bucket.Tally,
+		)
+	}
+	if bucket.RunnerUp.AuthorName != "Author 2" {
+		t.Errorf(
+			"expected runner-up to be Author 2 but got %s",
+			bucket.RunnerUp.AuthorName,
+		)
+	}
+	if bucket.TotalTally.Commits != 3 {
+		t.Errorf(
+			"expected 3 commits in total but got %d",
+			bucket.TotalTally.Commits,
+		)
+	}
+
+	names := []string{}
+	for tally := range anonymized[1].Tallies() {
+		names = append(names, tally.AuthorName)
+	}
+	if !slices.Equal(names, []string{"Others", "Author 1"}) {
+		t.Errorf("expected Author 1 and Others but got %v", names)
+	}
+
+	again := series.Anonymize()
+	if again[0].Tally.AuthorName != anonymized[0].Tally.AuthorName {
+		t.Errorf("expected the same pseudonyms on every call")
+	}
+}
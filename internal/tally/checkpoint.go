@@ -0,0 +1,159 @@
+package tally
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Bump this whenever the encoded format of a checkpoint changes, so that old
+// checkpoints are rejected instead of silently misread.
+const checkpointFormatVersion = 1
+
+// A daily time series saved along with the commit it was tallied up to.
+//
+// A later run can tally only the commits after Head and Combine() them into
+// Series instead of tallying the whole history again. This only gives the
+// right answer if both runs used the same TallyOpts.
+type Checkpoint struct {
+	Head   string     // Full hash of the last commit tallied
+	Series TimeSeries // Daily buckets, as returned by TallyCommitsByDate()
+}
+
+// Gob can only see exported fields, so we mirror the unexported ones here.
+type gobTally struct {
+	Name            string
+	Email           string
+	Commits         []string
+	Added           int64
+	Removed         int64
+	Churn           float64
+	FileShare       float64
+	Files           []string
+	Renames         map[string]string
+	FirstCommitTime time.Time
+	LastCommitTime  time.Time
+	NumTallied      int
+	KeepFiles       bool
+	CommitRefs      []CommitRef
+}
+
+type gobBucket struct {
+	Name    string
+	Time    time.Time
+	EndTime time.Time
+	Tallies map[string]gobTally
+}
+
+type gobCheckpoint struct {
+	Version int
+	Head    string
+	Buckets []gobBucket
+}
+
+func toSet(keys []string) map[string]bool {
+	out := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		out[k] = true
+	}
+	return out
+}
+
+func (t Tally) toGob() gobTally {
+	return gobTally{
+		Name:            t.name,
+		Email:           t.email,
+		Commits:         slices.Collect(maps.Keys(t.commitset)),
+		Added:           t.added,
+		Removed:         t.removed,
+		Churn:           t.churn,
+		FileShare:       t.fileShare,
+		Files:           slices.Collect(maps.Keys(t.fileset)),
+		Renames:         t.renames,
+		FirstCommitTime: t.firstCommitTime,
+		LastCommitTime:  t.lastCommitTime,
+		NumTallied:      t.numTallied,
+		KeepFiles:       t.keepFiles,
+		CommitRefs:      t.commits,
+	}
+}
+
+func (g gobTally) toTally() Tally {
+	return Tally{
+		name:            g.Name,
+		email:           g.Email,
+		commitset:       toSet(g.Commits),
+		added:           g.Added,
+		removed:         g.Removed,
+		churn:           g.Churn,
+		fileShare:       g.FileShare,
+		fileset:         toSet(g.Files),
+		renames:         g.Renames,
+		firstCommitTime: g.FirstCommitTime,
+		lastCommitTime:  g.LastCommitTime,
+		numTallied:      g.NumTallied,
+		keepFiles:       g.KeepFiles,
+		commits:         g.CommitRefs,
+	}
+}
+
+// Writes the checkpoint to w in Gob format.
+func (c Checkpoint) Write(w io.Writer) error {
+	out := gobCheckpoint{
+		Version: checkpointFormatVersion,
+		Head:    c.Head,
+		Buckets: make([]gobBucket, 0, len(c.Series)),
+	}
+
+	for _, bucket := range c.Series {
+		b := gobBucket{
+			Name:    bucket.Name,
+			Time:    bucket.Time,
+			EndTime: bucket.EndTime,
+			Tallies: make(map[string]gobTally, len(bucket.tallies)),
+		}
+		for key, tally := range bucket.tallies {
+			b.Tallies[key] = tally.toGob()
+		}
+		out.Buckets = append(out.Buckets, b)
+	}
+
+	err := gob.NewEncoder(w).Encode(out)
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// Reads a checkpoint previously written with Checkpoint.Write().
+func ReadCheckpoint(r io.Reader) (Checkpoint, error) {
+	var in gobCheckpoint
+	err := gob.NewDecoder(r).Decode(&in)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	if in.Version != checkpointFormatVersion {
+		return Checkpoint{}, fmt.Errorf(
+			"checkpoint has format version %d but expected %d",
+			in.Version,
+			checkpointFormatVersion,
+		)
+	}
+
+	series := make(TimeSeries, 0, len(in.Buckets))
+	for _, b := range in.Buckets {
+		bucket := newBucket(b.Name, b.Time)
+		bucket.EndTime = b.EndTime
+		for key, tally := range b.Tallies {
+			bucket.tallies[key] = tally.toTally()
+		}
+		series = append(series, bucket)
+	}
+
+	return Checkpoint{Head: in.Head, Series: series}, nil
+}
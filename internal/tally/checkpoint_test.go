@@ -0,0 +1,92 @@
+package tally
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	day := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	bucket := newBucket("2024-04-01", day)
+	bucket.EndTime = day.AddDate(0, 0, 1)
+	bucket.tallies["alice"] = Tally{
+		name:            "alice",
+		email:           "alice@example.com",
+		commitset:       map[string]bool{"abc": true, "def": true},
+		added:           3,
+		removed:         1,
+		churn:           3.5,
+		fileset:         map[string]bool{"foo.go": true},
+		renames:         map[string]string{"bar.go": "foo.go"},
+		firstCommitTime: day.Add(time.Hour),
+		lastCommitTime:  day.Add(2 * time.Hour),
+		numTallied:      2,
+		commits:         []CommitRef{{Hash: "abc", Time: day.Add(time.Hour)}},
+	}
+
+	checkpoint := Checkpoint{
+		Head:   "0123456789abcdef",
+		Series: TimeSeries{bucket},
+	}
+
+	var buf bytes.Buffer
+	err := checkpoint.Write(&buf)
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	read, err := ReadCheckpoint(&buf)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() returned error: %v", err)
+	}
+
+	if read.Head != checkpoint.Head {
+		t.Errorf("expected head %s but got %s", checkpoint.Head, read.Head)
+	}
+	if len(read.Series) != 1 {
+		t.Fatalf("expected 1 bucket but got %d", len(read.Series))
+	}
+
+	got := read.Series[0]
+	if !got.Time.Equal(bucket.Time) || !got.EndTime.Equal(bucket.EndTime) {
+		t.Errorf(
+			"expected bucket %v – %v but got %v – %v",
+			bucket.Time,
+			bucket.EndTime,
+			got.Time,
+			got.EndTime,
+		)
+	}
+
+	expected := bucket.tallies["alice"].Final()
+	if diff := cmp.Diff(expected, got.tallies["alice"].Final()); diff != "" {
+		t.Errorf("tally is wrong:\n%s", diff)
+	}
+
+	renames := got.tallies["alice"].renames
+	if renames["bar.go"] != "foo.go" {
+		t.Errorf("expected renames to survive but got %v", renames)
+	}
+
+	if diff := cmp.Diff(bucket.Commits(), got.Commits()); diff != "" {
+		t.Errorf("retained commits are wrong:\n%s", diff)
+	}
+}
+
+func TestReadCheckpointWrongVersion(t *testing.T) {
+	var buf bytes.Buffer
+	checkpoint := gobCheckpoint{Version: checkpointFormatVersion + 1}
+	err := gob.NewEncoder(&buf).Encode(checkpoint)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %v", err)
+	}
+
+	_, err = ReadCheckpoint(&buf)
+	if err == nil {
+		t.Errorf("expected error reading checkpoint with wrong version")
+	}
+}
@@ -0,0 +1,64 @@
+package tally
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Column headers for TimeSeries.WriteCSV. Don't reorder these; people import
+// this output into spreadsheets.
+var csvHeader = []string{
+	"bucket",
+	"time",
+	"winner",
+	"commits",
+	"lines added",
+	"lines removed",
+	"files",
+	"total commits",
+	"total lines added",
+	"total lines removed",
+	"total files",
+}
+
+func (b TimeBucket) toCSVRecord() []string {
+	return []string{
+		b.Name,
+		b.Time.Format(time.RFC3339),
+		b.Tally.AuthorName,
+		strconv.Itoa(b.Tally.Commits),
+		strconv.FormatInt(b.Tally.LinesAdded, 10),
+		strconv.FormatInt(b.Tally.LinesRemoved, 10),
+		strconv.Itoa(b.Tally.FileCount),
+		strconv.Itoa(b.TotalTally.Commits),
+		strconv.FormatInt(b.TotalTally.LinesAdded, 10),
+		strconv.FormatInt(b.TotalTally.LinesRemoved, 10),
+		strconv.Itoa(b.TotalTally.FileCount),
+	}
+}
+
+// Writes the time series as CSV with a header row and one row per bucket. The
+// buckets should have been ranked first.
+func (s TimeSeries) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, bucket := range s {
+		if err := cw.Write(bucket.toCSVRecord()); err != nil {
+			return fmt.Errorf("error writing CSV record: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %w", err)
+	}
+
+	return nil
+}
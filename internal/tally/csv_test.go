@@ -0,0 +1,47 @@
+package tally
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesWriteCSV(t *testing.T) {
+	series := TimeSeries{
+		TimeBucket{
+			Name: "2024-04-01",
+			Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"smith": {
+					name:       "Smith, Bob",
+					numTallied: 2,
+					added:      3,
+					fileset:    map[string]bool{"a.go": true},
+				},
+				"alice": {
+					name:       "alice",
+					numTallied: 1,
+					removed:    2,
+					fileset:    map[string]bool{"b.go": true},
+				},
+			},
+		}.Rank(CommitMode),
+		newBucket("2024-04-02", time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	var b strings.Builder
+	if err := series.WriteCSV(&b); err != nil {
+		t.Fatalf("WriteCSV() returned error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"bucket,time,winner,commits,lines added,lines removed,files," +
+			"total commits,total lines added,total lines removed,total files",
+		`2024-04-01,2024-04-01T00:00:00Z,"Smith, Bob",2,3,0,1,3,3,2,2`,
+		"2024-04-02,2024-04-02T00:00:00Z,,0,0,0,0,0,0,0,0",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf("expected CSV:\n%s\nbut got:\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,85 @@
+package tally
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type jsonTally struct {
+	AuthorName    string  `json:"name,omitempty"`
+	AuthorEmail   string  `json:"email,omitempty"`
+	Commits       int     `json:"commits"`
+	LinesAdded    int64   `json:"lines_added"`
+	LinesRemoved  int64   `json:"lines_removed"`
+	FileCount     int     `json:"files"`
+	FileShare     float64 `json:"file_share,omitempty"`
+	AvgCommitSize float64 `json:"avg_commit_size"`
+}
+
+type jsonBucket struct {
+	Name    string               `json:"name"`
+	Time    string               `json:"time"`
+	Winner  *jsonTally           `json:"winner,omitempty"` // Nil if empty
+	Total   jsonTally            `json:"total"`
+	Partial bool                 `json:"partial,omitempty"`
+	Authors map[string]jsonTally `json:"authors,omitempty"` // Keyed by author
+}
+
+func toJSONTally(t FinalTally) jsonTally {
+	return jsonTally{
+		AuthorName:    t.AuthorName,
+		AuthorEmail:   t.AuthorEmail,
+		Commits:       t.Commits,
+		LinesAdded:    t.LinesAdded,
+		LinesRemoved:  t.LinesRemoved,
+		FileCount:     t.FileCount,
+		FileShare:     t.FileShare,
+		AvgCommitSize: t.AvgCommitSize(),
+	}
+}
+
+func (b TimeBucket) toJSON(breakdown bool) jsonBucket {
+	out := jsonBucket{
+		Name:    b.Name,
+		Time:    b.Time.Format(time.RFC3339),
+		Total:   toJSONTally(b.TotalTally),
+		Partial: b.Partial,
+	}
+
+	// Total is for everyone, so would just have a random author's name
+	out.Total.AuthorName = ""
+	out.Total.AuthorEmail = ""
+
+	if b.Tally.AuthorName != "" || b.Tally.AuthorEmail != "" {
+		winner := toJSONTally(b.Tally)
+		out.Winner = &winner
+	}
+
+	if breakdown && len(b.tallies) > 0 {
+		out.Authors = map[string]jsonTally{}
+		for key, tally := range b.tallies {
+			out.Authors[key] = toJSONTally(tally.Final())
+		}
+	}
+
+	return out
+}
+
+// Marshals the bucket with the winning and total tallies. The bucket should
+// have been ranked first.
+func (b TimeBucket) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.toJSON(false))
+}
+
+// A time series that marshals to JSON including every author's tally in each
+// bucket, not just the winner. This can get big for long series.
+type DetailedTimeSeries TimeSeries
+
+func (s DetailedTimeSeries) MarshalJSON() ([]byte, error) {
+	buckets := []jsonBucket{}
+	for _, bucket := range s {
+		buckets = append(buckets, bucket.toJSON(true))
+	}
+
+	return json.Marshal(buckets)
+}
@@ -0,0 +1,75 @@
+package tally
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesMarshalJSON(t *testing.T) {
+	series := TimeSeries{
+		TimeBucket{
+			Name: "2024-04-01",
+			Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"alice": {name: "alice", numTallied: 2, added: 3},
+				"bob":   {name: "bob", numTallied: 1, removed: 2},
+			},
+		}.Rank(CommitMode),
+		newBucket("2024-04-02", time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	b, err := json.Marshal(series)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+
+	expected := `[` +
+		`{"name":"2024-04-01","time":"2024-04-01T00:00:00Z",` +
+		`"winner":{"name":"alice","commits":2,"lines_added":3,` +
+		`"lines_removed":0,"files":2,"avg_commit_size":1.5},` +
+		`"total":{"commits":3,"lines_added":3,"lines_removed":2,"files":3,` +
+		`"avg_commit_size":1.6666666666666667}},` +
+		`{"name":"2024-04-02","time":"2024-04-02T00:00:00Z",` +
+		`"total":{"commits":0,"lines_added":0,"lines_removed":0,"files":0,` +
+		`"avg_commit_size":0}}` +
+		`]`
+	if string(b) != expected {
+		t.Errorf("expected JSON:\n%s\nbut got:\n%s", expected, string(b))
+	}
+}
+
+func TestDetailedTimeSeriesMarshalJSON(t *testing.T) {
+	series := TimeSeries{
+		TimeBucket{
+			Name: "2024-04-01",
+			Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"alice": {name: "alice", numTallied: 2},
+				"bob":   {name: "bob", numTallied: 1},
+			},
+		}.Rank(CommitMode),
+	}
+
+	b, err := json.Marshal(DetailedTimeSeries(series))
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+
+	var out []struct {
+		Authors map[string]struct {
+			Commits int `json:"commits"`
+		} `json:"authors"`
+	}
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+
+	if len(out) != 1 || len(out[0].Authors) != 2 {
+		t.Fatalf("expected breakdown for 2 authors but got: %s", string(b))
+	}
+	if out[0].Authors["bob"].Commits != 1 {
+		t.Errorf("expected bob to have 1 commit but got: %s", string(b))
+	}
+}
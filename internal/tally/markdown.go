@@ -0,0 +1,79 @@
+package tally
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func markdownMetricHeader(mode TallyMode) string {
+	switch mode {
+	case CommitMode:
+		return "Commits"
+	case FilesMode:
+		return "Files"
+	case LinesMode, NetLinesMode, ChurnMode:
+		return "Lines (+/-)"
+	default:
+		panic("unrecognized tally mode in switch")
+	}
+}
+
+func markdownMetric(t FinalTally, mode TallyMode) string {
+	switch mode {
+	case CommitMode:
+		return strconv.Itoa(t.Commits)
+	case FilesMode:
+		return strconv.Itoa(t.FileCount)
+	case LinesMode, NetLinesMode, ChurnMode:
+		return fmt.Sprintf("+%d / -%d", t.LinesAdded, t.LinesRemoved)
+	default:
+		panic("unrecognized tally mode in switch")
+	}
+}
+
+// Escapes characters that would otherwise break out of a table cell.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// Writes the time series as a GitHub-flavored markdown table with one row per
+// bucket, giving the winner and the winner's metric for the given mode. The
+// footer row gives the metric for all authors across the whole series. The
+// buckets should have been ranked first using the same mode.
+func (s TimeSeries) WriteMarkdown(w io.Writer, mode TallyMode) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| Period | Winner | %s |\n", markdownMetricHeader(mode))
+	b.WriteString("| --- | --- | ---: |\n")
+
+	for _, bucket := range s {
+		metric := ""
+		if bucket.Tally.AuthorName != "" {
+			metric = markdownMetric(bucket.Tally, mode)
+		}
+
+		fmt.Fprintf(
+			&b,
+			"| %s | %s | %s |\n",
+			markdownEscape(bucket.Name),
+			markdownEscape(bucket.Tally.AuthorName),
+			metric,
+		)
+	}
+
+	fmt.Fprintf(
+		&b,
+		"| **Total** | | **%s** |\n",
+		markdownMetric(s.Totals(), mode),
+	)
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("error writing markdown table: %w", err)
+	}
+
+	return nil
+}
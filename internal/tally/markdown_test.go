@@ -0,0 +1,53 @@
+package tally
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesWriteMarkdown(t *testing.T) {
+	series := TimeSeries{
+		TimeBucket{
+			Name: "2024-04-01",
+			Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			tallies: map[string]Tally{
+				"bob": {
+					name:       "Bob | Ops",
+					commitset:  map[string]bool{"a": true},
+					numTallied: 1,
+					added:      3,
+					removed:    1,
+				},
+				"alice": {
+					name:       "alice",
+					commitset:  map[string]bool{"b": true},
+					numTallied: 1,
+					removed:    2,
+				},
+			},
+		}.Rank(LinesMode),
+		newBucket("2024-04-02", time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	var b strings.Builder
+	if err := series.WriteMarkdown(&b, LinesMode); err != nil {
+		t.Fatalf("WriteMarkdown() returned error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"| Period | Winner | Lines (+/-) |",
+		"| --- | --- | ---: |",
+		`| 2024-04-01 | Bob \| Ops | +3 / -1 |`,
+		"| 2024-04-02 |  |  |",
+		"| **Total** | | **+3 / -3** |",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf(
+			"expected markdown:\n%s\nbut got:\n%s",
+			expected,
+			b.String(),
+		)
+	}
+}
@@ -2,15 +2,103 @@
 package tally
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"iter"
+	"maps"
+	"math"
+	"path/filepath"
+	"regexp"
 	"slices"
+	"strings"
 	"time"
 
+	"golang.org/x/text/unicode/norm"
+
 	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/globutils"
 	"github.com/sinclairtarget/git-who/internal/utils/timeutils"
 )
 
+// Glob patterns for common generated, vendored, and lock files.
+var DefaultGeneratedPatterns = []string{
+	"**/package-lock.json",
+	"**/yarn.lock",
+	"**/go.sum",
+	"**/*.pb.go",
+	"**/vendor",
+}
+
+// A TallyOpts.PathWeight that ignores lines changed in files matching
+// DefaultGeneratedPatterns.
+func GeneratedFileWeight(path string) float64 {
+	for _, pattern := range DefaultGeneratedPatterns {
+		if globutils.Match(pattern, path) {
+			return 0
+		}
+	}
+
+	return 1
+}
+
+// Returns a TallyOpts.Decay that halves the weight of a commit for every
+// halfLife of age.
+func HalfLifeDecay(halfLife time.Duration) func(age time.Duration) float64 {
+	return func(age time.Duration) float64 {
+		return math.Pow(0.5, float64(max(age, 0))/float64(halfLife))
+	}
+}
+
+// Group that EmailDomainGroup() puts authors in when their email has no usable
+// domain.
+const NoDomainGroup = "(no domain)"
+
+// A TallyOpts.GroupBy that groups authors by the domain of their email, e.g.
+// "redhat.com". Emails without a domain and noreply addresses, like the ones
+// GitHub hands out, are grouped under NoDomainGroup.
+func EmailDomainGroup(c git.Commit) string {
+	email := strings.ToLower(c.AuthorEmail)
+	i := strings.LastIndexByte(email, '@')
+	if i < 0 || i == len(email)-1 {
+		return NoDomainGroup
+	}
+
+	if strings.Contains(email, "noreply") || strings.Contains(email, "no-reply") {
+		return NoDomainGroup
+	}
+
+	return email[i+1:]
+}
+
+// Groups that SignatureGroup() puts commits in.
+const (
+	SignedGroup   = "Signed"
+	UnsignedGroup = "Unsigned"
+)
+
+// A TallyOpts.GroupBy that groups commits by whether they are signed, e.g. to
+// chart how signing catches on. Bad signatures count as signed. The commits'
+// Signature needs to have been looked up; see git.Signatures().
+func SignatureGroup(c git.Commit) string {
+	if c.Signature.Signed() {
+		return SignedGroup
+	}
+
+	return UnsignedGroup
+}
+
+// A TallyOpts.DiffKey that groups file diffs by top-level directory. Files at
+// the root of the repo are grouped under ".".
+func TopLevelDirKey(c git.Commit, d git.FileDiff) string {
+	dir, _, ok := strings.Cut(d.Path, "/")
+	if !ok {
+		return "."
+	}
+
+	return dir
+}
+
 // Whether we rank authors by commit, lines, or files.
 type TallyMode int
 
@@ -20,34 +108,665 @@ const (
 	FilesMode
 	LastModifiedMode
 	FirstModifiedMode
+	NetLinesMode // Lines added minus lines removed; can be negative
+	ChurnMode    // Lines added plus weighted lines removed
+)
+
+// Size of the time buckets used by the timeline.
+type ResolutionMode int
+
+const (
+	AutoResolution ResolutionMode = iota // Picked based on duration
+	DailyResolution
+	WeeklyResolution
+	MonthlyResolution
+	QuarterlyResolution
+	YearlyResolution
+	IntervalResolution // Fixed number of days; see TallyOpts.Interval
+	RelativeResolution // Age bands, e.g. 30-90 days ago; see TallyOpts.Reference
+	ReleaseResolution  // Ranges between releases; see TallyOpts.Releases
+
+	// Weekdays only; see TallyOpts.WeekendToFriday
+	BusinessDayResolution
+)
+
+func (m ResolutionMode) String() string {
+	switch m {
+	case AutoResolution:
+		return "auto"
+	case DailyResolution:
+		return "daily"
+	case WeeklyResolution:
+		return "weekly"
+	case MonthlyResolution:
+		return "monthly"
+	case QuarterlyResolution:
+		return "quarterly"
+	case YearlyResolution:
+		return "yearly"
+	case IntervalResolution:
+		return "interval"
+	case RelativeResolution:
+		return "relative"
+	case ReleaseResolution:
+		return "release"
+	case BusinessDayResolution:
+		return "business-day"
+	default:
+		panic("unrecognized resolution mode in switch")
+	}
+}
+
+// A boundary between timeline buckets when using ReleaseResolution.
+type Release struct {
+	Name string
+	Time time.Time
+}
+
+// Which commit timestamp to use when placing commits on a timeline.
+type DateSource int
+
+const (
+	AuthorDate DateSource = iota
+	CommitterDate
 )
 
 const NoDiffPathname = ".git-who-no-diff-commits"
 
+// Patterns matching the names or emails of common bot accounts, e.g.
+// dependabot[bot].
+var DefaultBotPatterns = []string{"[bot]", "noreply@github.com"}
+
 type TallyOpts struct {
 	Mode        TallyMode
 	Key         func(c git.Commit) string // Unique ID for author
 	CountMerges bool
+
+	// The rest of the options are only used for timelines.
+
+	Resolution ResolutionMode
+	Location   *time.Location // Time zone for buckets; nil is local
+	DateSource DateSource     // Which commit date places it in a bucket
+
+	// If non-nil, used instead of Key to group each file diff on its own,
+	// e.g. by directory. The key is also used as the tally's name. A commit
+	// whose diffs have several keys counts once toward each of them.
+	DiffKey func(c git.Commit, d git.FileDiff) string
+
+	// If true, each co-author named in a commit's Co-authored-by trailers is
+	// credited with a full copy of the commit, as well as the author. The
+	// tallies overlap, so the bucket totals count the commit once per author.
+	// Ignored when DiffKey is set.
+	CoAuthors bool
+
+	// First month of the year for yearly and quarterly buckets. Zero means
+	// January. Any other month gives fiscal years, labeled by the calendar
+	// year they end in.
+	FiscalYearStart time.Month
+
+	// Length in days of each bucket when using IntervalResolution. Buckets
+	// are counted from the anchor, which defaults to the start of the
+	// timeline.
+	Interval int
+	Anchor   time.Time
+
+	// When using BusinessDayResolution, commits made on a Saturday or Sunday
+	// count toward the following Monday. If true, they count toward the
+	// preceding Friday instead.
+	WeekendToFriday bool
+
+	// Overrides the labels given to timeline buckets, by resolution mode.
+	// Each value is a time.Format() layout applied to the start of the
+	// bucket, e.g. "01/2006" for MonthlyResolution. Interval buckets are
+	// labeled with the layout applied to their first and last day. Modes
+	// without a layout, RelativeResolution and ReleaseResolution keep the
+	// default labels.
+	LabelFormats map[ResolutionMode]string
+
+	// Time that ages are measured back from when using RelativeResolution or
+	// Decay. If zero, RelativeResolution uses the time of the most recent
+	// commit tallied, while Decay uses AsOf or else the time the tally
+	// starts.
+	Reference time.Time
+
+	// Boundaries for ReleaseResolution, e.g. from git tag. Each bucket spans
+	// the commits from one release up to the next and is labeled with both
+	// names, e.g. "v1.2..v1.3". Commits before the first release and after
+	// the last one get buckets of their own.
+	Releases []Release
+
+	// Window of time for timelines. Commits outside the window are ignored
+	// and the timeline spans the whole window. Zero values mean unbounded.
+	Since time.Time
+	Until time.Time
+
+	// Drop empty buckets from the start and end of timelines, so that the
+	// timeline only spans the period with activity.
+	TrimEmpty bool
+
+	// Most buckets a timeline may have before tallying fails with
+	// ErrTooManyBuckets, so that a bogus date (e.g. a commit from 1970 or an
+	// end far in the future) can't run the process out of memory. Zero means
+	// DefaultMaxBuckets.
+	MaxBuckets int
+
+	// Pins the end of the timeline, so that the resolution and buckets don't
+	// change as time passes. Commits after this time are ignored. If zero,
+	// the timeline ends now (or with the last commit, for non-HEAD revs).
+	AsOf time.Time
+
+	// If non-empty, only commits by matching authors are tallied. Each
+	// pattern matches an author email exactly or a substring of the name.
+	AuthorFilter []string
+
+	// Commits by authors whose name or email contains one of these patterns
+	// are ignored. See DefaultBotPatterns.
+	ExcludeAuthors []string
+
+	// If non-nil, only commits with a subject line matching this regexp are
+	// tallied, e.g. "^feat:".
+	MessageFilter *regexp.Regexp
+
+	// If true, only commits with a verified signature are tallied, e.g. to
+	// audit signing. The commits' Signature needs to have been looked up; see
+	// git.Signatures().
+	VerifiedOnly bool
+
+	// Applied to each commit before computing the key. Git already applies
+	// the repo's own .mailmap, so this is only needed for other mailmaps.
+	Mailmap git.Mailmap
+
+	// Applied to each commit after the Mailmap, so that an alias wins over
+	// both the mailmap and the commit's own identity.
+	Aliases git.Aliases
+
+	// If non-nil, authors are credited to teams instead, looked up by
+	// lowercased email after applying the Mailmap and Aliases. Each team gets
+	// one tally named after the team. Authors missing from the map are
+	// credited to UnknownTeam, or to themselves as usual if UnknownTeam is
+	// empty. Ignored when DiffKey is set.
+	Teams       map[string]string
+	UnknownTeam string
+
+	// If non-nil, authors are credited to the group returned for each commit
+	// instead, e.g. by EmailDomainGroup(). Each group gets one tally named
+	// after the group. Ignored when Teams or DiffKey is set.
+	GroupBy func(c git.Commit) string
+
+	// If non-empty, only file diffs with one of these extensions (e.g. ".go")
+	// are tallied. Commits with no matching diffs are ignored entirely.
+	Extensions []string
+
+	// If non-empty, only file diffs with a path matching one of these globs
+	// (e.g. "internal/tally/**") are tallied. Uses the same glob semantics as
+	// git pathspecs. Commits with no matching diffs are ignored entirely.
+	PathFilter []string
+
+	// File diffs with a path matching these gitignore-style patterns are not
+	// tallied, e.g. the patterns from a .git-who-ignore file. Applied on top
+	// of Extensions and PathFilter. Commits with no remaining diffs are
+	// ignored entirely.
+	ExcludePaths []string
+
+	// If true, each merge commit is tallied using its diff against its first
+	// parent, so the merge's author is credited with the whole change it
+	// landed, and commits that only joined the history through a merge's
+	// other parents are ignored. This tallies each change once, when it
+	// landed, as with git log --first-parent. Takes precedence over
+	// CountMerges. The commits need Parents, and commits whose children
+	// weren't tallied (e.g. because of path limiting) count as landed.
+	MergeDiffs bool
+
+	// If true, each file is only credited to the earliest commit that touched
+	// it, so authors are ranked by the files they created. Commits that
+	// didn't create any files are ignored.
+	FirstCommitOnly bool
+
+	// How much a removed line counts relative to an added line in ChurnMode.
+	// Nil means 1.0, which makes ChurnMode equivalent to LinesMode.
+	ChurnWeight *float64
+
+	// Scales the lines added and removed in each file diff by the weight for
+	// its path, e.g. to discount generated files. Nil weighs every path as
+	// 1.0.
+	PathWeight func(path string) float64
+
+	// If positive, the lines added and the lines removed by each file diff
+	// are each capped at this many, so that a huge generated diff (e.g. a
+	// regenerated lock file) can't swamp a bucket. The file still counts as
+	// touched. Applied before PathWeight and Decay.
+	MaxDiffLines int
+
+	// Scales the lines added and removed in each commit by a weight for the
+	// commit's age (see Reference), e.g. HalfLifeDecay(), so that recent work
+	// outranks old work. Nil weighs every commit as 1.0.
+	Decay func(age time.Duration) float64
+
+	// Keep the paths of the files each author touched on finalized tallies,
+	// so that FinalTally.Combine() can count distinct files exactly. Costs
+	// memory.
+	RetainFiles bool
+
+	// Keep a CommitRef for each commit tallied, so that callers can list the
+	// commits behind a bucket with TimeBucket.Commits(). Costs memory on long
+	// timelines.
+	RetainCommits bool
+
+	// If true, each file touched in a timeline bucket is split evenly among
+	// the N authors who touched it, so each gets 1/N of the file in
+	// FinalTally.FileShare. The shares in a bucket add up to its distinct
+	// file count. FilesMode ranks by the shares.
+	FractionalFiles bool
+
+	// If non-nil, called with the number of commits read so far after every
+	// ProgressInterval commits, e.g. to show a counter on long runs. Only
+	// used by TallyCommitsByDate().
+	Progress func(commitsProcessed int)
+}
+
+// How many commits TallyCommitsByDate() reads between calls to
+// TallyOpts.Progress.
+const ProgressInterval = 1000
+
+// Checks that the options make sense together, so that mistakes are reported
+// up front instead of as an odd failure or an empty result partway through a
+// tally.
+func (opts TallyOpts) Validate() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("invalid tally options: %w", err)
+		}
+	}()
+
+	if opts.Mode < CommitMode || opts.Mode > ChurnMode {
+		return fmt.Errorf("unrecognized mode %d", opts.Mode)
+	}
+	if opts.Resolution < AutoResolution ||
+		opts.Resolution > BusinessDayResolution {
+		return fmt.Errorf("unrecognized resolution %d", opts.Resolution)
+	}
+	if opts.Key == nil && opts.DiffKey == nil {
+		return errors.New("Key must be set")
+	}
+
+	if opts.Interval < 0 {
+		return fmt.Errorf(
+			"Interval must not be negative, got %d",
+			opts.Interval,
+		)
+	}
+	if opts.MaxDiffLines < 0 {
+		return fmt.Errorf(
+			"MaxDiffLines must not be negative, got %d",
+			opts.MaxDiffLines,
+		)
+	}
+	if opts.MaxBuckets < 0 {
+		return fmt.Errorf(
+			"MaxBuckets must not be negative, got %d",
+			opts.MaxBuckets,
+		)
+	}
+	if opts.Resolution == IntervalResolution && opts.Interval == 0 {
+		return errors.New("IntervalResolution needs an Interval")
+	}
+	if opts.Resolution == ReleaseResolution && len(opts.Releases) == 0 {
+		return errors.New("ReleaseResolution needs at least one release")
+	}
+	if opts.FiscalYearStart < 0 || opts.FiscalYearStart > time.December {
+		return fmt.Errorf(
+			"FiscalYearStart must be a month, got %d",
+			opts.FiscalYearStart,
+		)
+	}
+	if opts.ChurnWeight != nil && *opts.ChurnWeight < 0 {
+		return fmt.Errorf(
+			"ChurnWeight must not be negative, got %g",
+			*opts.ChurnWeight,
+		)
+	}
+
+	if !opts.Since.IsZero() && !opts.Until.IsZero() &&
+		opts.Since.After(opts.Until) {
+		return fmt.Errorf(
+			"Since (%s) is after Until (%s)",
+			opts.Since.Format(time.DateOnly),
+			opts.Until.Format(time.DateOnly),
+		)
+	}
+	if !opts.Since.IsZero() && !opts.AsOf.IsZero() &&
+		opts.Since.After(opts.AsOf) {
+		return fmt.Errorf(
+			"Since (%s) is after AsOf (%s)",
+			opts.Since.Format(time.DateOnly),
+			opts.AsOf.Format(time.DateOnly),
+		)
+	}
+
+	for _, pattern := range opts.PathFilter {
+		if err := globutils.Validate(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the lines added and removed by the diff, capped at MaxDiffLines and
+// scaled by its path weight and the commit's decay weight.
+func (opts TallyOpts) weighLines(
+	commit git.Commit,
+	diff git.FileDiff,
+) (int64, int64) {
+	if opts.MaxDiffLines > 0 {
+		diff.LinesAdded = min(diff.LinesAdded, opts.MaxDiffLines)
+		diff.LinesRemoved = min(diff.LinesRemoved, opts.MaxDiffLines)
+	}
+
+	if opts.PathWeight == nil && opts.Decay == nil {
+		return int64(diff.LinesAdded), int64(diff.LinesRemoved)
+	}
+
+	weight := 1.0
+	if opts.PathWeight != nil {
+		weight *= opts.PathWeight(diff.Path)
+	}
+	if opts.Decay != nil {
+		weight *= opts.Decay(opts.Reference.Sub(opts.commitDate(commit)))
+	}
+
+	added := int64(math.Round(float64(diff.LinesAdded) * weight))
+	removed := int64(math.Round(float64(diff.LinesRemoved) * weight))
+	return added, removed
+}
+
+// Returns the team or other group the commit's author belongs to. Returns
+// false if the author should be tallied on their own.
+func (opts TallyOpts) group(commit git.Commit) (string, bool) {
+	if opts.Teams == nil {
+		if opts.GroupBy != nil {
+			return opts.GroupBy(commit), true
+		}
+		return "", false
+	}
+
+	team, ok := opts.Teams[strings.ToLower(commit.AuthorEmail)]
+	if ok {
+		return team, true
+	}
+
+	return opts.UnknownTeam, opts.UnknownTeam != ""
+}
+
+// Pins the time that Decay measures ages back from, so that it doesn't move
+// while tallying.
+func (opts TallyOpts) withDecayReference() TallyOpts {
+	if opts.Decay == nil || !opts.Reference.IsZero() {
+		return opts
+	}
+
+	if !opts.AsOf.IsZero() {
+		opts.Reference = opts.AsOf
+	} else {
+		opts.Reference = time.Now()
+	}
+
+	return opts
+}
+
+func (opts TallyOpts) location() *time.Location {
+	if opts.Location == nil {
+		return time.Local
+	}
+
+	return opts.Location
+}
+
+func (opts TallyOpts) maxBuckets() int {
+	if opts.MaxBuckets == 0 {
+		return DefaultMaxBuckets
+	}
+
+	return opts.MaxBuckets
+}
+
+func (opts TallyOpts) churnWeight() float64 {
+	if opts.ChurnWeight == nil {
+		return 1.0
+	}
+
+	return *opts.ChurnWeight
+}
+
+func (opts TallyOpts) fiscalYearStart() time.Month {
+	if opts.FiscalYearStart == 0 {
+		return time.January
+	}
+
+	return opts.FiscalYearStart
+}
+
+// Returns the date of the commit used for timelines.
+func (opts TallyOpts) commitDate(commit git.Commit) time.Time {
+	switch opts.DateSource {
+	case AuthorDate:
+		return commit.Date
+	case CommitterDate:
+		return commit.CommitterDate
+	default:
+		panic("unrecognized date source in switch")
+	}
+}
+
+// Whether the commit falls within the Since / Until window.
+func (opts TallyOpts) inWindow(commit git.Commit) bool {
+	date := opts.commitDate(commit)
+
+	if !opts.Since.IsZero() && date.Before(opts.Since) {
+		return false
+	}
+
+	if !opts.Until.IsZero() && date.After(opts.Until) {
+		return false
+	}
+
+	if !opts.AsOf.IsZero() && date.After(opts.AsOf) {
+		return false
+	}
+
+	return true
+}
+
+// Returns the commit with its author name in Unicode normal form C, so that
+// e.g. "José" with a precomposed "é" and "José" with a combining accent get
+// the same key.
+func normalizeAuthor(commit git.Commit) git.Commit {
+	commit.AuthorName = norm.NFC.String(commit.AuthorName)
+	return commit
+}
+
+// Whether the commit's author matches one of the patterns in AuthorFilter.
+func (opts TallyOpts) matchesAuthorFilter(commit git.Commit) bool {
+	if len(opts.AuthorFilter) == 0 {
+		return true
+	}
+
+	for _, pattern := range opts.AuthorFilter {
+		if commit.AuthorEmail == pattern {
+			return true
+		}
+
+		if strings.Contains(commit.AuthorName, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the commit's subject matches the MessageFilter.
+func (opts TallyOpts) matchesMessageFilter(commit git.Commit) bool {
+	if opts.MessageFilter == nil {
+		return true
+	}
+
+	return opts.MessageFilter.MatchString(commit.Subject)
+}
+
+// Whether the commit passes the VerifiedOnly filter.
+func (opts TallyOpts) matchesSignatureFilter(commit git.Commit) bool {
+	return !opts.VerifiedOnly || commit.Signature.Verified()
+}
+
+// Whether the commit's author matches one of the patterns in ExcludeAuthors.
+func (opts TallyOpts) isExcludedAuthor(commit git.Commit) bool {
+	for _, pattern := range opts.ExcludeAuthors {
+		if strings.Contains(commit.AuthorName, pattern) ||
+			strings.Contains(commit.AuthorEmail, pattern) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Whether we need --stat and --summary data from git log for this tally mode
 func (opts TallyOpts) IsDiffMode() bool {
-	return opts.Mode == FilesMode || opts.Mode == LinesMode
+	return opts.Mode == FilesMode ||
+		opts.Mode == LinesMode ||
+		opts.Mode == NetLinesMode ||
+		opts.Mode == ChurnMode
+}
+
+// Whether we need --stat and --summary data from git log, either for the tally
+// mode or to filter or group commits by the files they touch.
+func (opts TallyOpts) NeedsDiffs() bool {
+	return opts.IsDiffMode() ||
+		opts.filtersDiffs() ||
+		opts.FirstCommitOnly ||
+		opts.DiffKey != nil
+}
+
+// Whether some file diffs might be excluded from the tally.
+func (opts TallyOpts) filtersDiffs() bool {
+	return len(opts.Extensions) > 0 ||
+		len(opts.PathFilter) > 0 ||
+		len(opts.ExcludePaths) > 0
+}
+
+// Whether the file diff should count toward the tally.
+func (opts TallyOpts) includeDiff(diff git.FileDiff) bool {
+	if len(opts.Extensions) > 0 {
+		ext := filepath.Ext(diff.Path)
+		if !slices.Contains(opts.Extensions, ext) {
+			return false
+		}
+	}
+
+	if len(opts.PathFilter) > 0 {
+		matches := slices.ContainsFunc(opts.PathFilter, func(pattern string) bool {
+			return globutils.Match(pattern, diff.Path)
+		})
+		if !matches {
+			return false
+		}
+	}
+
+	if globutils.MatchIgnore(opts.ExcludePaths, diff.Path) {
+		return false
+	}
+
+	return true
+}
+
+// Returns the file diffs in the commit that should count toward the tally.
+//
+// The second return value is false if a filter is in effect and the commit has
+// no matching diffs, in which case the commit should not be tallied at all.
+func (opts TallyOpts) filterDiffs(commit git.Commit) ([]git.FileDiff, bool) {
+	if !opts.filtersDiffs() {
+		return commit.FileDiffs, true
+	}
+
+	diffs := []git.FileDiff{}
+	for _, diff := range commit.FileDiffs {
+		if opts.includeDiff(diff) {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, len(diffs) > 0
 }
 
 // Metrics tallied for a single author while walking git log.
 //
-// This kind of tally cannot be combined with others because intermediate
-// information has been lost.
+// This kind of tally cannot be combined exactly with others because
+// intermediate information has been lost. See Combine().
 type FinalTally struct {
 	AuthorName      string
 	AuthorEmail     string
-	Commits         int // Num commits editing paths in tree by this author
-	LinesAdded      int // Num lines added to paths in tree by author
-	LinesRemoved    int // Num lines deleted from paths in tree by author
-	FileCount       int // Num of file paths in working dir touched by author
+	Commits         int     // Num commits editing paths in tree by this author
+	LinesAdded      int64   // Num lines added to paths in tree by author
+	LinesRemoved    int64   // Num lines deleted from paths in tree by author
+	FileCount       int     // Num of file paths in working dir touched by author
+	FileShare       float64 // Files split among their authors; see FractionalFiles
+	Churn           float64 // Lines added plus weighted removed; timelines only
 	FirstCommitTime time.Time
 	LastCommitTime  time.Time
+
+	// Sorted paths of the files counted in FileCount. Nil unless
+	// TallyOpts.RetainFiles is set.
+	Files []string
+}
+
+// Combines two finalized tallies, e.g. summaries of the same author from
+// different runs.
+//
+// This is approximate. The set of commits behind each tally is gone, so a
+// commit counted in both tallies gets counted twice. The same goes for files,
+// unless both tallies retained their files (see TallyOpts.RetainFiles), in
+// which case the file count is exact. Combine Tally values before finalizing
+// them if the counts need to be exact.
+func (a FinalTally) Combine(b FinalTally) FinalTally {
+	first := timeutils.Min(a.FirstCommitTime, b.FirstCommitTime)
+	if a.FirstCommitTime.IsZero() {
+		first = b.FirstCommitTime
+	} else if b.FirstCommitTime.IsZero() {
+		first = a.FirstCommitTime
+	}
+
+	fileCount := a.FileCount + b.FileCount
+	var files []string
+	if a.Files != nil && b.Files != nil {
+		files = slices.Compact(slices.Sorted(slices.Values(
+			slices.Concat(a.Files, b.Files),
+		)))
+		fileCount = len(files)
+	}
+
+	return FinalTally{
+		AuthorName:      or(a.AuthorName, b.AuthorName),
+		AuthorEmail:     or(a.AuthorEmail, b.AuthorEmail),
+		Commits:         a.Commits + b.Commits,
+		LinesAdded:      a.LinesAdded + b.LinesAdded,
+		LinesRemoved:    a.LinesRemoved + b.LinesRemoved,
+		FileCount:       fileCount,
+		FileShare:       a.FileShare + b.FileShare,
+		Churn:           a.Churn + b.Churn,
+		FirstCommitTime: first,
+		LastCommitTime:  timeutils.Max(a.LastCommitTime, b.LastCommitTime),
+		Files:           files,
+	}
+}
+
+// Average number of lines added or removed per commit. Returns zero if there
+// are no commits.
+func (t FinalTally) AvgCommitSize() float64 {
+	if t.Commits == 0 {
+		return 0
+	}
+
+	return float64(t.LinesAdded+t.LinesRemoved) / float64(t.Commits)
 }
 
 func (t FinalTally) SortKey(mode TallyMode) int64 {
@@ -57,7 +776,11 @@ func (t FinalTally) SortKey(mode TallyMode) int64 {
 	case FilesMode:
 		return int64(t.FileCount)
 	case LinesMode:
-		return int64(t.LinesAdded + t.LinesRemoved)
+		return t.LinesAdded + t.LinesRemoved
+	case NetLinesMode:
+		return t.LinesAdded - t.LinesRemoved
+	case ChurnMode:
+		return int64(math.Round(t.Churn))
 	case FirstModifiedMode:
 		return -t.FirstCommitTime.Unix()
 	case LastModifiedMode:
@@ -68,6 +791,12 @@ func (t FinalTally) SortKey(mode TallyMode) int64 {
 }
 
 func (a FinalTally) Compare(b FinalTally, mode TallyMode) int {
+	// FileShare is only non-zero with TallyOpts.FractionalFiles, and is more
+	// precise than FileCount when it is
+	if mode == FilesMode && a.FileShare != b.FileShare {
+		return cmp.Compare(a.FileShare, b.FileShare)
+	}
+
 	aRank := a.SortKey(mode)
 	bRank := b.SortKey(mode)
 
@@ -77,8 +806,32 @@ func (a FinalTally) Compare(b FinalTally, mode TallyMode) int {
 		return 1
 	}
 
-	// Break ties with last edited
-	return a.LastCommitTime.Compare(b.LastCommitTime)
+	// Break ties with total lines, then commits, then last edited
+	aLines := a.LinesAdded + a.LinesRemoved
+	bLines := b.LinesAdded + b.LinesRemoved
+	if aLines != bLines {
+		return cmp.Compare(aLines, bLines)
+	}
+
+	if a.Commits != b.Commits {
+		return cmp.Compare(a.Commits, b.Commits)
+	}
+
+	if c := a.LastCommitTime.Compare(b.LastCommitTime); c != 0 {
+		return c
+	}
+
+	return compareIdentity(a, b)
+}
+
+// Makes sure ties are broken deterministically. Reversed so that authors sort
+// alphabetically when ranked in descending order.
+func compareIdentity(a, b FinalTally) int {
+	if a.AuthorEmail != b.AuthorEmail {
+		return strings.Compare(b.AuthorEmail, a.AuthorEmail)
+	}
+
+	return strings.Compare(b.AuthorName, a.AuthorName)
 }
 
 // A non-final tally that can be combined with other tallies and then finalized
@@ -86,13 +839,18 @@ type Tally struct {
 	name            string
 	email           string
 	commitset       map[string]bool
-	added           int
-	removed         int
+	added           int64
+	removed         int64
+	churn           float64 // Only tallied for timelines
+	fileShare       float64 // Only computed with TallyOpts.FractionalFiles
 	fileset         map[string]bool
+	renames         map[string]string // Old path -> new path; timelines only
 	firstCommitTime time.Time
 	lastCommitTime  time.Time
 	// Can be used to count Tally objs when we don't need to disambiguate
 	numTallied int
+	keepFiles  bool        // Whether Final() should include the fileset
+	commits    []CommitRef // Only kept with TallyOpts.RetainCommits
 }
 
 func or(a, b string) string {
@@ -119,6 +877,57 @@ func unionInPlace(a, b map[string]bool) map[string]bool {
 	return union
 }
 
+// Returns a tally of the given commit alone, for building up tallies outside
+// this package using Combine(). The options are applied as they would be for a
+// timeline, except that Key, DiffKey, and CoAuthors are ignored, so the tally
+// is the author's alone. With Teams or GroupBy, the tally is the one for the
+// author's group. Returns false if the options filter the commit out.
+func NewTally(commit git.Commit, opts TallyOpts) (Tally, bool) {
+	opts.Key = func(c git.Commit) string { return "" }
+	opts.DiffKey = nil
+	opts.CoAuthors = false
+	opts = opts.withDecayReference()
+
+	tallies := map[string]Tally{}
+	tallyCommit(tallies, commit, opts)
+
+	// Same identity that tallyCommit() groups by
+	author := normalizeAuthor(opts.Aliases.Apply(opts.Mailmap.Apply(commit)))
+	key := ""
+	if group, ok := opts.group(author); ok {
+		key = group
+	}
+
+	tally, ok := tallies[key]
+	return tally, ok
+}
+
+func (t Tally) Name() string {
+	return t.name
+}
+
+func (t Tally) Email() string {
+	return t.email
+}
+
+// Number of commits (or other things, e.g. paths) added into the tally.
+func (t Tally) NumTallied() int {
+	return t.numTallied
+}
+
+func (t Tally) LinesAdded() int64 {
+	return t.added
+}
+
+func (t Tally) LinesRemoved() int64 {
+	return t.removed
+}
+
+// Number of distinct files touched, counting renamed files once.
+func (t Tally) FileCount() int {
+	return t.fileCount()
+}
+
 func (a Tally) Combine(b Tally) Tally {
 	return Tally{
 		name:            or(a.name, b.name),
@@ -126,11 +935,78 @@ func (a Tally) Combine(b Tally) Tally {
 		commitset:       unionInPlace(a.commitset, b.commitset),
 		added:           a.added + b.added,
 		removed:         a.removed + b.removed,
+		churn:           a.churn + b.churn,
+		fileShare:       a.fileShare + b.fileShare,
 		fileset:         unionInPlace(a.fileset, b.fileset),
+		renames:         mergeRenames(a.renames, b.renames),
 		firstCommitTime: timeutils.Min(a.firstCommitTime, b.firstCommitTime),
 		lastCommitTime:  timeutils.Max(a.lastCommitTime, b.lastCommitTime),
 		numTallied:      a.numTallied + b.numTallied,
+		keepFiles:       a.keepFiles || b.keepFiles,
+		commits:         slices.Concat(a.commits, b.commits),
+	}
+}
+
+// Returns a copy of the tally that doesn't share any sets with the original.
+func (t Tally) clone() Tally {
+	t.commitset = maps.Clone(t.commitset)
+	t.fileset = maps.Clone(t.fileset)
+	t.renames = maps.Clone(t.renames)
+	t.commits = slices.Clone(t.commits)
+	return t
+}
+
+func mergeRenames(a, b map[string]string) map[string]string {
+	if len(b) == 0 {
+		return a
+	} else if len(a) == 0 {
+		return b
+	}
+
+	merged := maps.Clone(a)
+	maps.Copy(merged, b)
+	return merged
+}
+
+// Follows renames from the given path to the file's most recent path.
+func canonicalPath(path string, renames map[string]string) string {
+	visited := map[string]bool{}
+	for !visited[path] {
+		visited[path] = true
+
+		next, ok := renames[path]
+		if !ok {
+			return path
+		}
+		path = next
+	}
+
+	// Renamed in a cycle. Pick any path in the cycle, as long as it's the
+	// same one each time
+	return slices.Min(slices.Collect(maps.Keys(visited)))
+}
+
+// Number of distinct files in the fileset, counting renamed files once.
+func (t Tally) fileCount() int {
+	if len(t.renames) == 0 {
+		return len(t.fileset)
+	}
+
+	return len(t.canonicalFiles())
+}
+
+// The fileset with each renamed file under its most recent path.
+func (t Tally) canonicalFiles() map[string]bool {
+	if len(t.renames) == 0 {
+		return t.fileset
+	}
+
+	canonical := map[string]bool{}
+	for path := range t.fileset {
+		canonical[canonicalPath(path, t.renames)] = true
 	}
+
+	return canonical
 }
 
 func (t Tally) Final() FinalTally {
@@ -141,13 +1017,18 @@ func (t Tally) Final() FinalTally {
 
 	files := t.numTallied // Not using fileset? Fallback to numTallied
 	if len(t.fileset) > 0 {
-		files = len(t.fileset)
+		files = t.fileCount()
 	}
 
 	if t.name == "" && t.email == "" {
 		panic("tally finalized but has no name and no email")
 	}
 
+	var paths []string
+	if t.keepFiles {
+		paths = slices.Sorted(maps.Keys(t.canonicalFiles()))
+	}
+
 	return FinalTally{
 		AuthorName:      t.name,
 		AuthorEmail:     t.email,
@@ -155,8 +1036,11 @@ func (t Tally) Final() FinalTally {
 		LinesAdded:      t.added,
 		LinesRemoved:    t.removed,
 		FileCount:       files,
+		FileShare:       t.fileShare,
+		Churn:           t.churn,
 		FirstCommitTime: t.firstCommitTime,
 		LastCommitTime:  t.lastCommitTime,
+		Files:           paths,
 	}
 }
 
@@ -230,6 +1114,7 @@ func TallyCommits(
 				continue
 			}
 
+			commit = normalizeAuthor(commit)
 			key := opts.Key(commit)
 
 			tally, ok := tallies[key]
@@ -266,6 +1151,40 @@ func TallyCommits(
 	return tallies, nil
 }
 
+// Tallies who last modified each line in a tree, given the commits returned
+// by git.SurvivingCommits() for it. This is a snapshot: each author is
+// credited with the lines that are theirs right now, not with every line they
+// ever changed.
+//
+// The tallies are ranked by lines. LinesAdded counts the author's surviving
+// lines, LinesRemoved is always zero, FileCount counts the files containing
+// at least one of their lines, and Commits counts the commits that still own
+// a line. The mode in the opts is ignored.
+func TallyLastModified(
+	commits iter.Seq2[git.Commit, error],
+	opts TallyOpts,
+) (_ []FinalTally, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error tallying last modified lines: %w", err)
+		}
+	}()
+
+	opts.Mode = LinesMode
+
+	err = opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	tallies, err := TallyCommits(commits, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return Rank(tallies, LinesMode), nil
+}
+
 // Tally metrics per author per path.
 func TallyCommitsByPath(
 	commits iter.Seq2[git.Commit, error],
@@ -283,6 +1202,7 @@ func TallyCommitsByPath(
 			continue
 		}
 
+		commit = normalizeAuthor(commit)
 		key := opts.Key(commit)
 
 		pathTallies, ok := tallies[key]
@@ -341,8 +1261,8 @@ func TallyCommitsByPath(
 				if !commit.IsMerge {
 					// Only non-merge commits contribute to files / lines
 					tally.numTallied = 1
-					tally.added += diff.LinesAdded
-					tally.removed += diff.LinesRemoved
+					tally.added += int64(diff.LinesAdded)
+					tally.removed += int64(diff.LinesRemoved)
 				}
 
 				pathTallies[diff.Path] = tally
@@ -357,13 +1277,31 @@ func TallyCommitsByPath(
 
 // Sort tallies according to mode.
 func Rank(tallies map[string]Tally, mode TallyMode) []FinalTally {
+	return RankFunc(tallies, func(a, b FinalTally) int {
+		return a.Compare(b, mode)
+	})
+}
+
+// Sort tallies so that the greatest tally according to cmp comes first.
+//
+// cmp should return a negative number when a < b, a positive number when
+// a > b, and zero otherwise, as for slices.SortFunc(). Ties are broken by
+// author email and name.
+func RankFunc(
+	tallies map[string]Tally,
+	cmp func(a, b FinalTally) int,
+) []FinalTally {
 	final := []FinalTally{}
 	for _, t := range tallies {
 		final = append(final, t.Final())
 	}
 
 	slices.SortFunc(final, func(a, b FinalTally) int {
-		return -a.Compare(b, mode)
+		if c := cmp(a, b); c != 0 {
+			return -c
+		}
+
+		return -compareIdentity(a, b)
 	})
 	return final
 }
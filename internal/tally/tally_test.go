@@ -3,6 +3,7 @@ package tally_test
 import (
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -94,3 +95,444 @@ func TestTallyCommits(t *testing.T) {
 		t.Errorf("jim's tally is wrong:\n%s", diff)
 	}
 }
+
+func TestRankNetLinesNegative(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:         "bim.txt",
+					LinesAdded:   10,
+					LinesRemoved: 2,
+				},
+			},
+		},
+		git.Commit{
+			Hash:        "bab",
+			ShortHash:   "bab",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:         "bim.txt",
+					LinesAdded:   0,
+					LinesRemoved: 50,
+				},
+			},
+		},
+	}
+
+	seq := iterutils.WithoutErrors(slices.Values(commits))
+	opts := tally.TallyOpts{
+		Mode: tally.NetLinesMode,
+		Key: func(c git.Commit) string {
+			return c.AuthorEmail
+		},
+	}
+	tallies, err := tally.TallyCommits(seq, opts)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	rankedTallies := tally.Rank(tallies, opts.Mode)
+	if len(rankedTallies) != 2 {
+		t.Fatalf("expected 2 tallies but got %d", len(rankedTallies))
+	}
+
+	if rankedTallies[0].AuthorName != "bob" {
+		t.Errorf("expected bob to rank first but got %s", rankedTallies[0].AuthorName)
+	}
+
+	jim := rankedTallies[1]
+	if key := jim.SortKey(opts.Mode); key != -50 {
+		t.Errorf("expected jim's net lines to be -50 but got %d", key)
+	}
+}
+
+func TestAvgCommitSize(t *testing.T) {
+	final := tally.FinalTally{Commits: 4, LinesAdded: 7, LinesRemoved: 3}
+	if avg := final.AvgCommitSize(); avg != 2.5 {
+		t.Errorf("expected average commit size of 2.5 but got %f", avg)
+	}
+
+	empty := tally.FinalTally{}
+	if avg := empty.AvgCommitSize(); avg != 0 {
+		t.Errorf("expected average commit size of 0 but got %f", avg)
+	}
+}
+
+func TestFinalTallyCombine(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	a := tally.FinalTally{
+		AuthorName:      "bob",
+		Commits:         2,
+		LinesAdded:      10,
+		LinesRemoved:    3,
+		FileCount:       2,
+		FirstCommitTime: mar,
+		LastCommitTime:  mar,
+	}
+	b := tally.FinalTally{
+		AuthorName:      "bob",
+		AuthorEmail:     "bob@mail.com",
+		Commits:         1,
+		LinesAdded:      5,
+		FileCount:       1,
+		FirstCommitTime: jan,
+		LastCommitTime:  jan,
+	}
+
+	expected := tally.FinalTally{
+		AuthorName:      "bob",
+		AuthorEmail:     "bob@mail.com",
+		Commits:         3,
+		LinesAdded:      15,
+		LinesRemoved:    3,
+		FileCount:       3,
+		FirstCommitTime: jan,
+		LastCommitTime:  mar,
+	}
+	if diff := cmp.Diff(expected, a.Combine(b)); diff != "" {
+		t.Errorf("combined tally is wrong:\n%s", diff)
+	}
+
+	if diff := cmp.Diff(a, a.Combine(tally.FinalTally{})); diff != "" {
+		t.Errorf("combining with an empty tally changed it:\n%s", diff)
+	}
+}
+
+func TestNewTally(t *testing.T) {
+	bob := git.Commit{
+		Hash:        "baa",
+		AuthorName:  "bob",
+		AuthorEmail: "bob@mail.com",
+		Date:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		FileDiffs: []git.FileDiff{
+			{Path: "foo.go", LinesAdded: 4, LinesRemoved: 1},
+			{Path: "bar.go", LinesAdded: 2},
+		},
+	}
+	bob2 := bob
+	bob2.Hash = "bab"
+	bob2.FileDiffs = []git.FileDiff{{Path: "foo.go", LinesRemoved: 3}}
+
+	opts := tally.TallyOpts{Mode: tally.LinesMode}
+
+	a, ok := tally.NewTally(bob, opts)
+	if !ok {
+		t.Fatalf("expected commit to be tallied")
+	}
+	b, ok := tally.NewTally(bob2, opts)
+	if !ok {
+		t.Fatalf("expected commit to be tallied")
+	}
+
+	combined := a.Combine(b)
+	if combined.Name() != "bob" || combined.Email() != "bob@mail.com" {
+		t.Errorf("wrong identity: %s <%s>", combined.Name(), combined.Email())
+	}
+	if combined.NumTallied() != 2 {
+		t.Errorf("expected 2 tallied, got %d", combined.NumTallied())
+	}
+	if combined.LinesAdded() != 6 || combined.LinesRemoved() != 4 {
+		t.Errorf(
+			"wrong lines: +%d -%d",
+			combined.LinesAdded(),
+			combined.LinesRemoved(),
+		)
+	}
+	if combined.FileCount() != 2 {
+		t.Errorf("expected 2 files, got %d", combined.FileCount())
+	}
+	if combined.Final().Commits != 2 {
+		t.Errorf("expected 2 commits, got %d", combined.Final().Commits)
+	}
+
+	opts.AuthorFilter = []string{"alice"}
+	if _, ok := tally.NewTally(bob, opts); ok {
+		t.Errorf("expected filtered commit not to be tallied")
+	}
+}
+
+func TestNewTallyCoAuthors(t *testing.T) {
+	commit := git.Commit{
+		Hash:        "baa",
+		AuthorName:  "bob",
+		AuthorEmail: "bob@mail.com",
+		Date:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CoAuthors:   []git.Author{{Name: "alice", Email: "alice@mail.com"}},
+		FileDiffs:   []git.FileDiff{{Path: "foo.go", LinesAdded: 4}},
+	}
+
+	opts := tally.TallyOpts{Mode: tally.LinesMode, CoAuthors: true}
+	result, ok := tally.NewTally(commit, opts)
+	if !ok {
+		t.Fatalf("expected commit to be tallied")
+	}
+
+	if result.Name() != "bob" {
+		t.Errorf("expected tally for bob, got %s", result.Name())
+	}
+	if result.NumTallied() != 1 {
+		t.Errorf("expected 1 tallied, got %d", result.NumTallied())
+	}
+	if result.LinesAdded() != 4 {
+		t.Errorf("expected 4 lines added, got %d", result.LinesAdded())
+	}
+}
+
+func TestNewTallyTeams(t *testing.T) {
+	bob := git.Commit{
+		Hash:        "baa",
+		AuthorName:  "bob",
+		AuthorEmail: "bob@mail.com",
+		Date:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	alice := git.Commit{
+		Hash:        "bab",
+		AuthorName:  "alice",
+		AuthorEmail: "alice@mail.com",
+		Date:        time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	carol := git.Commit{
+		Hash:        "bac",
+		AuthorName:  "carol",
+		AuthorEmail: "carol@mail.com",
+		Date:        time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Teams: map[string]string{
+			"bob@mail.com":   "Platform",
+			"alice@mail.com": "Platform",
+		},
+		UnknownTeam: "Unknown",
+	}
+
+	a, ok := tally.NewTally(bob, opts)
+	if !ok {
+		t.Fatalf("expected commit to be tallied")
+	}
+	b, ok := tally.NewTally(alice, opts)
+	if !ok {
+		t.Fatalf("expected commit to be tallied")
+	}
+
+	combined := a.Combine(b)
+	if combined.Name() != "Platform" {
+		t.Errorf("expected tally for Platform, got %s", combined.Name())
+	}
+	if combined.NumTallied() != 2 {
+		t.Errorf("expected 2 tallied, got %d", combined.NumTallied())
+	}
+
+	c, ok := tally.NewTally(carol, opts)
+	if !ok || c.Name() != "Unknown" {
+		t.Errorf("expected carol to be tallied for Unknown, got %s", c.Name())
+	}
+
+	// Without an unknown team, unlisted authors are tallied on their own
+	opts.UnknownTeam = ""
+	c, ok = tally.NewTally(carol, opts)
+	if !ok || c.Name() != "carol" {
+		t.Errorf("expected carol to be tallied alone, got %s", c.Name())
+	}
+}
+
+func TestTallyCommitsNormalizesNames(t *testing.T) {
+	commits := []git.Commit{
+		{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "Jos\u00e9", // Precomposed
+			AuthorEmail: "jose@mac.com",
+		},
+		{
+			Hash:        "bab",
+			ShortHash:   "bab",
+			AuthorName:  "Jose\u0301", // Combining accent
+			AuthorEmail: "jose@linux.com",
+		},
+	}
+
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+	tallies, err := tally.TallyCommits(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	if len(tallies) != 1 {
+		t.Fatalf("expected 1 tally but got %d", len(tallies))
+	}
+
+	final := tallies["Jos\u00e9"].Final()
+	if final.Commits != 2 {
+		t.Errorf("expected 2 commits but got %d", final.Commits)
+	}
+}
+
+func TestEmailDomainGroup(t *testing.T) {
+	tests := []struct {
+		email string
+		exp   string
+	}{
+		{"bob@RedHat.com", "redhat.com"},
+		{"alice@mail.google.com", "mail.google.com"},
+		{"123+carol@users.noreply.github.com", tally.NoDomainGroup},
+		{"no-reply@example.com", tally.NoDomainGroup},
+		{"dan", tally.NoDomainGroup},
+		{"dan@", tally.NoDomainGroup},
+		{"", tally.NoDomainGroup},
+	}
+
+	for _, test := range tests {
+		group := tally.EmailDomainGroup(git.Commit{AuthorEmail: test.email})
+		if group != test.exp {
+			t.Errorf(
+				"expected \"%s\" to be grouped under %s but got %s",
+				test.email,
+				test.exp,
+				group,
+			)
+		}
+	}
+}
+
+func TestTallyOptsValidate(t *testing.T) {
+	key := func(c git.Commit) string { return c.AuthorEmail }
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		opts  tally.TallyOpts
+		valid bool
+	}{
+		{"defaults", tally.TallyOpts{Key: key}, true},
+		{"no key", tally.TallyOpts{}, false},
+		{
+			"window",
+			tally.TallyOpts{Key: key, Since: jan, Until: feb},
+			true,
+		},
+		{
+			"backwards window",
+			tally.TallyOpts{Key: key, Since: feb, Until: jan},
+			false,
+		},
+		{
+			"as of before since",
+			tally.TallyOpts{Key: key, Since: feb, AsOf: jan},
+			false,
+		},
+		{
+			"interval without length",
+			tally.TallyOpts{Key: key, Resolution: tally.IntervalResolution},
+			false,
+		},
+		{
+			"releases without any releases",
+			tally.TallyOpts{Key: key, Resolution: tally.ReleaseResolution},
+			false,
+		},
+		{
+			"bad fiscal year",
+			tally.TallyOpts{Key: key, FiscalYearStart: 13},
+			false,
+		},
+		{
+			"bad glob",
+			tally.TallyOpts{Key: key, PathFilter: []string{"internal/[a"}},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.opts.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected no error but got: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: expected an error but got none", test.name)
+		}
+	}
+}
+
+func TestTallyLastModified(t *testing.T) {
+	// As returned by git.SurvivingCommits()
+	commits := []git.Commit{
+		{
+			Hash:        "aaa",
+			ShortHash:   "aaa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			FileDiffs: []git.FileDiff{
+				{Path: "foo.go", LinesAdded: 3},
+				{Path: "bar.go", LinesAdded: 1},
+			},
+		},
+		{
+			Hash:        "bbb",
+			ShortHash:   "bbb",
+			AuthorName:  "alice",
+			AuthorEmail: "alice@mail.com",
+			Date:        time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			FileDiffs: []git.FileDiff{
+				{Path: "foo.go", LinesAdded: 5},
+			},
+		},
+		{
+			Hash:        "ccc",
+			ShortHash:   "ccc",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			FileDiffs: []git.FileDiff{
+				{Path: "foo.go", LinesAdded: 2},
+			},
+		},
+	}
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode, // Ignored
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	tallies, err := tally.TallyLastModified(
+		iterutils.WithoutErrors(slices.Values(commits)),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("TallyLastModified() returned error: %v", err)
+	}
+
+	if len(tallies) != 2 {
+		t.Fatalf("expected 2 tallies but got %d", len(tallies))
+	}
+
+	bob := tallies[0]
+	if bob.AuthorName != "bob" {
+		t.Fatalf("expected bob to rank first but got %s", bob.AuthorName)
+	}
+	if bob.LinesAdded != 6 || bob.Commits != 2 || bob.FileCount != 2 {
+		t.Errorf(
+			"expected bob to have 6 lines in 2 commits and 2 files but got %v",
+			bob,
+		)
+	}
+	if tallies[1].LinesAdded != 5 {
+		t.Errorf("expected alice to have 5 lines but got %v", tallies[1])
+	}
+}
@@ -1,7 +1,10 @@
 // https://stackoverflow.com/questions/28322997/how-to-get-a-list-of-values-into-a-flag-in-golang
 package flagutils
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 type SliceFlag []string
 
@@ -13,3 +16,28 @@ func (s *SliceFlag) Set(value string) error {
 	*s = append(*s, value)
 	return nil
 }
+
+// A float flag that records whether it was given, for flags where zero is a
+// meaningful value.
+type OptionalFloat struct {
+	Value float64
+	IsSet bool
+}
+
+func (f *OptionalFloat) String() string {
+	if !f.IsSet {
+		return ""
+	}
+	return strconv.FormatFloat(f.Value, 'g', -1, 64)
+}
+
+func (f *OptionalFloat) Set(value string) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+
+	f.Value = v
+	f.IsSet = true
+	return nil
+}
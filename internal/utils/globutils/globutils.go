@@ -0,0 +1,104 @@
+// Matches slash-separated paths against glob patterns the way git does for
+// pathspecs with the "glob" magic.
+package globutils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Returns an error if the pattern is malformed.
+func Validate(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("bad glob pattern \"%s\": %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Whether the path matches the pattern.
+//
+// "*", "?", and character classes never match a slash. A "**" segment matches
+// zero or more directories. As with git pathspecs, a pattern also matches
+// everything inside a directory it matches, so "internal" matches
+// "internal/git/git.go".
+//
+// Malformed patterns never match. Use Validate() to check patterns first.
+func Match(pattern string, name string) bool {
+	return matchSegments(
+		strings.Split(strings.Trim(pattern, "/"), "/"),
+		strings.Split(name, "/"),
+	)
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return true // Anything left is inside a matching directory
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	ok, err := path.Match(pattern[0], name[0])
+	if err != nil || !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// Whether the path is ignored by the patterns, which use gitignore syntax.
+//
+// A pattern with no slash except a trailing one matches at any depth, so
+// "*.pb.go" matches "api/v1/foo.pb.go". Otherwise the pattern is relative to
+// the repo root. A trailing slash only matches directories, i.e. everything
+// inside them. A leading "!" re-includes paths ignored by an earlier pattern;
+// the last matching pattern wins.
+func MatchIgnore(patterns []string, name string) bool {
+	ignored := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if negated {
+			pattern = pattern[1:]
+		}
+
+		if matchIgnorePattern(pattern, name) {
+			ignored = !negated
+		}
+	}
+
+	return ignored
+}
+
+func matchIgnorePattern(pattern string, name string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	if dirOnly {
+		// Paths are always files, so must be inside the directory
+		pattern += "/**/*"
+	}
+
+	return Match(pattern, name)
+}
@@ -0,0 +1,179 @@
+package globutils_test
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/utils/globutils"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		exp     bool
+	}{
+		{
+			name:    "exact",
+			pattern: "main.go",
+			path:    "main.go",
+			exp:     true,
+		},
+		{
+			name:    "star_within_segment",
+			pattern: "*.go",
+			path:    "main.go",
+			exp:     true,
+		},
+		{
+			name:    "star_does_not_cross_slash",
+			pattern: "*.go",
+			path:    "internal/main.go",
+			exp:     false,
+		},
+		{
+			name:    "double_star_prefix",
+			pattern: "**/*.go",
+			path:    "internal/tally/tally.go",
+			exp:     true,
+		},
+		{
+			name:    "double_star_prefix_zero_dirs",
+			pattern: "**/*.go",
+			path:    "main.go",
+			exp:     true,
+		},
+		{
+			name:    "double_star_suffix",
+			pattern: "internal/tally/**",
+			path:    "internal/tally/bucket.go",
+			exp:     true,
+		},
+		{
+			name:    "double_star_suffix_other_dir",
+			pattern: "internal/tally/**",
+			path:    "internal/git/git.go",
+			exp:     false,
+		},
+		{
+			name:    "double_star_middle",
+			pattern: "internal/**/git.go",
+			path:    "internal/a/b/git.go",
+			exp:     true,
+		},
+		{
+			name:    "leading_directory",
+			pattern: "internal",
+			path:    "internal/git/git.go",
+			exp:     true,
+		},
+		{
+			name:    "partial_segment",
+			pattern: "intern",
+			path:    "internal/git/git.go",
+			exp:     false,
+		},
+		{
+			name:    "malformed",
+			pattern: "[",
+			path:    "[",
+			exp:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans := globutils.Match(test.pattern, test.path)
+			if ans != test.exp {
+				t.Errorf(
+					"expected Match(\"%s\", \"%s\") to be %v",
+					test.pattern,
+					test.path,
+					test.exp,
+				)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := globutils.Validate("internal/**/*.go"); err != nil {
+		t.Errorf("expected valid pattern but got: %v", err)
+	}
+
+	if err := globutils.Validate("internal/[a-"); err == nil {
+		t.Errorf("expected error for malformed pattern")
+	}
+}
+
+func TestMatchIgnore(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		exp      bool
+	}{
+		{
+			name:     "basename_any_depth",
+			patterns: []string{"*.pb.go"},
+			path:     "api/v1/foo.pb.go",
+			exp:      true,
+		},
+		{
+			name:     "anchored",
+			patterns: []string{"/vendor"},
+			path:     "internal/vendor/lib.go",
+			exp:      false,
+		},
+		{
+			name:     "anchored_match",
+			patterns: []string{"/vendor"},
+			path:     "vendor/lib.go",
+			exp:      true,
+		},
+		{
+			name:     "dir_only",
+			patterns: []string{"build/"},
+			path:     "cmd/build/out.js",
+			exp:      true,
+		},
+		{
+			name:     "dir_only_not_file",
+			patterns: []string{"build/"},
+			path:     "cmd/build",
+			exp:      false,
+		},
+		{
+			name:     "negated",
+			patterns: []string{"*.gen.go", "!keep.gen.go"},
+			path:     "internal/keep.gen.go",
+			exp:      false,
+		},
+		{
+			name:     "last_match_wins",
+			patterns: []string{"!keep.gen.go", "*.gen.go"},
+			path:     "internal/keep.gen.go",
+			exp:      true,
+		},
+		{
+			name:     "no_match",
+			patterns: []string{"*.pb.go", "vendor/"},
+			path:     "main.go",
+			exp:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans := globutils.MatchIgnore(test.patterns, test.path)
+			if ans != test.exp {
+				t.Errorf(
+					"expected MatchIgnore(%v, \"%s\") to be %v",
+					test.patterns,
+					test.path,
+					test.exp,
+				)
+			}
+		})
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/tally"
 	"github.com/sinclairtarget/git-who/internal/utils/flagutils"
+	"github.com/sinclairtarget/git-who/internal/utils/globutils"
 )
 
 var Commit = "unknown"
@@ -126,6 +129,10 @@ func tableCmd() command {
 	firstModifiedMode := flagSet.Bool("c", false, "Sort by first modified (created)")
 	lastModifiedMode := flagSet.Bool("m", false, "Sort by last modified")
 	limit := flagSet.Int("n", 10, "Limit rows in table (set to 0 for no limit)")
+	surviving := flagSet.Bool("surviving", false, strings.TrimSpace(`
+Credit each line in the given revision to whoever last modified it, using git
+blame, instead of summing up history. Sorts by lines. Can be slow for big trees
+	`))
 
 	filterFlags := addFilterFlags(flagSet)
 
@@ -169,6 +176,17 @@ Usage: git-who table [options...] [revisions...] [[--] paths...]
 				return errors.New("-n flag must be a positive integer")
 			}
 
+			if *surviving {
+				if mode != tally.CommitMode {
+					return errors.New("-surviving cannot be used with sort flags")
+				}
+				if *filterFlags.since != "" || *filterFlags.until != "" ||
+					len(filterFlags.authors) > 0 || len(filterFlags.nauthors) > 0 {
+					return errors.New("-surviving cannot be used with filter flags")
+				}
+				mode = tally.LinesMode
+			}
+
 			revs, paths, err := git.ParseArgs(args)
 			if err != nil {
 				return err
@@ -181,6 +199,7 @@ Usage: git-who table [options...] [revisions...] [[--] paths...]
 				*showEmail,
 				*countMerges,
 				*limit,
+				*surviving,
 				*filterFlags.since,
 				*filterFlags.until,
 				filterFlags.authors,
@@ -270,8 +289,196 @@ func histCmd() command {
 
 	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
 	useFiles := flagSet.Bool("f", false, "Rank authors by files touched")
+	useNetLines := flagSet.Bool(
+		"net",
+		false,
+		"Rank authors by lines added minus lines removed",
+	)
+	var churn flagutils.OptionalFloat
+	flagSet.Var(&churn, "churn", strings.TrimSpace(`
+Rank authors by lines added plus lines removed times the given weight, e.g. 0.5
+(set to 0 to count only lines added)
+	`))
 	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	anonymize := flagSet.Bool(
+		"anonymize",
+		false,
+		"Replace author names and emails with pseudonyms like \"Author 1\"",
+	)
 	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	firstCommitOnly := flagSet.Bool(
+		"first-commit",
+		false,
+		"Credit each file only to the commit that first touched it",
+	)
+	mergeDiffs := flagSet.Bool("merge-diffs", false, strings.TrimSpace(`
+Credit each merge with everything it landed, as diffed against its first
+parent, and ignore the commits it merged in. Implies -merges
+	`))
+	firstParent := flagSet.Bool("first-parent", false, strings.TrimSpace(`
+Only follow the first parent of merges, so that the timeline shows what landed
+on the mainline and when. Implies -merge-diffs
+	`))
+	surviving := flagSet.Bool("surviving", false, strings.TrimSpace(`
+Only count lines that still exist in the given revision, using git blame. Runs
+one blame per file, one after another, so it can be slow for big trees
+	`))
+	noBots := flagSet.Bool(
+		"no-bots",
+		false,
+		"Ignore commits by bots like dependabot[bot]",
+	)
+	coAuthors := flagSet.Bool(
+		"co-authors",
+		false,
+		"Also credit co-authors named in Co-authored-by trailers",
+	)
+	noGenerated := flagSet.Bool(
+		"no-generated",
+		false,
+		"Don't count lines changed in lock files, vendor/, and generated code",
+	)
+	maxDiffLines := flagSet.Int("max-diff-lines", 0, strings.TrimSpace(`
+Count at most this many lines added and removed per file in each commit, so
+that huge generated changes don't swamp the timeline (set to 0 for no limit)
+	`))
+	halfLife := flagSet.String("half-life", "", strings.TrimSpace(`
+Weigh lines by how recently they changed, halving the weight of older lines
+every given period, e.g. 180d or 26w
+	`))
+	resolution := flagSet.String("resolution", "auto", strings.TrimSpace(`
+Size of time buckets (auto, day, business-day, week, month, quarter, or year)
+	`))
+	weekendToFriday := flagSet.Bool("weekend-to-friday", false, strings.TrimSpace(`
+With -resolution business-day, count weekend commits toward the preceding
+Friday instead of the following Monday
+	`))
+	fiscalYearStart := flagSet.Int("fiscal-year-start", 1, strings.TrimSpace(`
+Month (1-12) in which yearly and quarterly buckets start, for fiscal years
+	`))
+	trimEmpty := flagSet.Bool(
+		"trim",
+		false,
+		"Leave out empty time buckets at the start and end of the timeline",
+	)
+	interval := flagSet.String("interval", "", strings.TrimSpace(`
+Use time buckets of a fixed length, e.g. 14d or 2w. Overrides -resolution
+	`))
+	anchor := flagSet.String("anchor", "", strings.TrimSpace(`
+Start one of the -interval buckets on this date (defaults to the start of the
+timeline). See git-commit(1) for valid date formats
+	`))
+	labelFormat := flagSet.String("label-format", "", strings.TrimSpace(`
+Label time buckets using this Go time layout for the start of each bucket,
+e.g. "01/2006" or "2 Jan 2006". Can't be used with -relative-dates or
+-releases, which name buckets by age or by tag
+	`))
+	relativeDates := flagSet.Bool("relative-dates", false, strings.TrimSpace(`
+Bucket commits by age (e.g. 30-90 days ago) relative to the latest commit
+	`))
+	releases := flagSet.Bool("releases", false, strings.TrimSpace(`
+Bucket commits by the ranges between consecutive tags (e.g. v1.2..v1.3) instead
+of by date
+	`))
+	clock := flagSet.String("clock", "", strings.TrimSpace(`
+Tally by hour of day or day of week across the whole history instead of by date
+(hour or weekday)
+	`))
+	tz := flagSet.String(
+		"tz",
+		"",
+		"Time zone used to bucket commits, e.g. UTC (defaults to local time)",
+	)
+	useCommitterDate := flagSet.Bool(
+		"committer-date",
+		false,
+		"Place commits on the timeline by committer date instead of author date",
+	)
+	asOf := flagSet.String("as-of", "", strings.TrimSpace(`
+End the timeline at the given date (defaults to now), so that repeated runs
+produce the same buckets. See git-commit(1) for valid date formats
+	`))
+	checkpointPath := flagSet.String("checkpoint", "", strings.TrimSpace(`
+Save the daily tallies to this file and, on later runs, only tally commits made
+since. Use the same options and revision every time
+	`))
+	useJson := flagSet.Bool("json", false, "Output as json")
+	useCsv := flagSet.Bool("csv", false, "Output as csv")
+	outFormat := flagSet.String("format", "", strings.TrimSpace(`
+Output in another format (md for a markdown table of each time bucket's winner)
+	`))
+	showDebug := flagSet.Bool(
+		"debug",
+		false,
+		"Print every author's tally in each time bucket instead of a plot",
+	)
+	showBreakdown := flagSet.Bool(
+		"breakdown",
+		false,
+		"Include every author's tally in each time bucket in json output",
+	)
+	byDir := flagSet.Bool(
+		"by-dir",
+		false,
+		"Tally changes by top-level directory instead of by author",
+	)
+	top := flagSet.Int("top", 0, strings.TrimSpace(`
+Keep only the top authors in each time bucket for json and debug output,
+folding the rest into "Others" (set to 0 for no limit)
+	`))
+	minCommits := flagSet.Int("min-commits", 0, strings.TrimSpace(`
+Fold authors with fewer commits than this over the whole timeline into "Others"
+	`))
+	minShare := flagSet.Float64("min-share", 0, strings.TrimSpace(`
+Fold authors with less than this percentage (e.g. 5) of a time bucket's total
+into "Others" for json and debug output
+	`))
+	mailmapPath := flagSet.String(
+		"mailmap",
+		"",
+		"Path to a mailmap file used to merge author identities",
+	)
+	aliasesPath := flagSet.String("author-json", "", strings.TrimSpace(`
+Path to a JSON file of author aliases, as a list of {"canonical": "Name
+<email>", "aliases": [emails or names...]} entries. Overrides -mailmap
+	`))
+	byDomain := flagSet.Bool(
+		"by-domain",
+		false,
+		"Tally changes by author email domain instead of by author",
+	)
+	bySignature := flagSet.Bool("by-signature", false, strings.TrimSpace(`
+Tally signed and unsigned commits instead of authors, to chart how signing
+catches on. Can be slow, since git checks every signature
+	`))
+	verifiedOnly := flagSet.Bool("verified", false, strings.TrimSpace(`
+Only count commits with a good signature from a trusted key. Can be slow, since
+git checks every signature
+	`))
+	teamsPath := flagSet.String("teams", "", strings.TrimSpace(`
+Path to a file mapping author emails to teams, to tally by team instead of by
+author. Each line is a team name followed by emails, e.g. "Platform <a@b.com>"
+	`))
+	unknownTeam := flagSet.String("unknown-team", "Unknown", strings.TrimSpace(`
+Team for authors not listed in the -teams file (set to "" to tally them on
+their own)
+	`))
+	var exts flagutils.SliceFlag
+	flagSet.Var(&exts, "ext", strings.TrimSpace(`
+Only count changes to files with this extension, e.g. .go. Can be specified
+multiple times
+	`))
+	var globs flagutils.SliceFlag
+	flagSet.Var(&globs, "glob", strings.TrimSpace(`
+Only count changes to files matching this glob, e.g. internal/**. Unlike a
+pathspec, other changes in the same commits are ignored. Can be specified
+multiple times
+	`))
+
+	message := flagSet.String("message", "", strings.TrimSpace(`
+Only count commits with a subject matching this regular expression, e.g.
+^feat:
+	`))
 
 	filterFlags := addFilterFlags(flagSet)
 
@@ -295,28 +502,285 @@ Usage: git-who hist [options...] [revisions...] [[--] paths...]
 				return fmt.Errorf("could not parse args: %w", err)
 			}
 
-			if !isOnlyOne(*useLines, *useFiles) {
+			if !isOnlyOne(*useLines, *useFiles, *useNetLines, churn.IsSet) {
 				return errors.New("all ranking flags are mutually exclusive")
 			}
 
+			var churnWeight *float64
+			if churn.IsSet {
+				if churn.Value < 0 {
+					return errors.New("-churn flag must not be negative")
+				}
+				churnWeight = &churn.Value
+			}
+
+			if *outFormat != "" && *outFormat != "md" {
+				return fmt.Errorf(
+					"unrecognized output format \"%s\"",
+					*outFormat,
+				)
+			}
+			useMarkdown := *outFormat == "md"
+
+			if !isOnlyOne(*useJson, *useCsv, useMarkdown, *showDebug) {
+				return errors.New("all output format flags are mutually exclusive")
+			}
+
 			mode := tally.CommitMode
 			if *useLines {
 				mode = tally.LinesMode
 			} else if *useFiles {
 				mode = tally.FilesMode
+			} else if *useNetLines {
+				mode = tally.NetLinesMode
+			} else if churn.IsSet {
+				mode = tally.ChurnMode
 			}
 
-			return hist(
-				revs,
-				paths,
-				mode,
-				*showEmail,
-				*countMerges,
-				*filterFlags.since,
-				*filterFlags.until,
-				filterFlags.authors,
-				filterFlags.nauthors,
-			)
+			resolutionMode, err := parseResolution(*resolution)
+			if err != nil {
+				return err
+			}
+
+			if *weekendToFriday &&
+				resolutionMode != tally.BusinessDayResolution {
+				return errors.New(
+					"-weekend-to-friday can only be used with " +
+						"-resolution business-day",
+				)
+			}
+
+			if *fiscalYearStart < 1 || *fiscalYearStart > 12 {
+				return fmt.Errorf(
+					"-fiscal-year-start must be between 1 and 12, got %d",
+					*fiscalYearStart,
+				)
+			}
+
+			var intervalDays int
+			if *interval != "" {
+				intervalDays, err = parseInterval(*interval)
+				if err != nil {
+					return err
+				}
+				resolutionMode = tally.IntervalResolution
+			} else if *anchor != "" {
+				return errors.New("-anchor can only be used with -interval")
+			}
+
+			if *relativeDates {
+				if *interval != "" {
+					return errors.New(
+						"-relative-dates and -interval are mutually exclusive",
+					)
+				}
+				resolutionMode = tally.RelativeResolution
+			}
+
+			if *releases {
+				if *interval != "" || *relativeDates {
+					return errors.New(
+						"-releases cannot be used with -interval or " +
+							"-relative-dates",
+					)
+				}
+				resolutionMode = tally.ReleaseResolution
+			}
+
+			if *labelFormat != "" && (*relativeDates || *releases) {
+				return errors.New(
+					"-label-format cannot be used with -relative-dates or " +
+						"-releases",
+				)
+			}
+
+			var clockMode tally.ClockMode
+			if *clock != "" {
+				clockMode, err = parseClock(*clock)
+				if err != nil {
+					return err
+				}
+				if *surviving {
+					return errors.New(
+						"-clock and -surviving are mutually exclusive",
+					)
+				}
+			}
+
+			if *firstParent && *surviving {
+				return errors.New(
+					"-first-parent and -surviving are mutually exclusive",
+				)
+			}
+
+			loc := time.Local
+			if *tz != "" {
+				loc, err = time.LoadLocation(*tz)
+				if err != nil {
+					return fmt.Errorf("could not parse -tz flag: %w", err)
+				}
+			}
+
+			var mailmap git.Mailmap
+			if *mailmapPath != "" {
+				mailmap, err = git.ReadMailmap(*mailmapPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			var aliases git.Aliases
+			if *aliasesPath != "" {
+				aliases, err = git.ReadAliases(*aliasesPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			var teams map[string]string
+			if *teamsPath != "" {
+				if *byDir {
+					return errors.New("-teams and -by-dir are mutually exclusive")
+				}
+
+				teams, err = readTeamsFile(*teamsPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			if *byDomain && (*byDir || *teamsPath != "") {
+				return errors.New(
+					"-by-domain cannot be used with -by-dir or -teams",
+				)
+			}
+			if *bySignature && (*byDir || *byDomain || *teamsPath != "") {
+				return errors.New(
+					"-by-signature cannot be used with -by-dir, -by-domain, " +
+						"or -teams",
+				)
+			}
+
+			dateSource := tally.AuthorDate
+			if *useCommitterDate {
+				dateSource = tally.CommitterDate
+			}
+
+			extensions := []string{}
+			for _, ext := range exts {
+				if !strings.HasPrefix(ext, ".") {
+					ext = "." + ext
+				}
+				extensions = append(extensions, ext)
+			}
+
+			for _, glob := range globs {
+				if err := globutils.Validate(glob); err != nil {
+					return fmt.Errorf("could not parse -glob flag: %w", err)
+				}
+			}
+
+			if *surviving {
+				if len(filterFlags.authors) > 0 || len(filterFlags.nauthors) > 0 {
+					return errors.New(
+						"-surviving cannot be used with -author or -nauthor",
+					)
+				}
+				if *firstCommitOnly {
+					return errors.New(
+						"-surviving and -first-commit are mutually exclusive",
+					)
+				}
+			}
+
+			if *top < 0 {
+				return errors.New("-top flag must be a positive integer")
+			}
+			if *minCommits < 0 {
+				return errors.New("-min-commits flag must be a positive integer")
+			}
+			if *maxDiffLines < 0 {
+				return errors.New("-max-diff-lines flag must be a positive integer")
+			}
+			if *minShare < 0 || *minShare > 100 {
+				return errors.New("-min-share flag must be between 0 and 100")
+			}
+
+			var halfLifeDays int
+			if *halfLife != "" {
+				halfLifeDays, err = parseInterval(*halfLife)
+				if err != nil {
+					return fmt.Errorf("could not parse -half-life flag: %w", err)
+				}
+			}
+
+			var messageFilter *regexp.Regexp
+			if *message != "" {
+				messageFilter, err = regexp.Compile(*message)
+				if err != nil {
+					return fmt.Errorf("could not parse -message flag: %w", err)
+				}
+			}
+
+			opts := histOpts{
+				mode:            mode,
+				churnWeight:     churnWeight,
+				resolution:      resolutionMode,
+				weekendToFriday: *weekendToFriday,
+				fiscalYearStart: time.Month(*fiscalYearStart),
+				interval:        intervalDays,
+				anchor:          *anchor,
+				labelFormat:     *labelFormat,
+				trimEmpty:       *trimEmpty,
+				byClock:         *clock != "",
+				clock:           clockMode,
+				loc:             loc,
+				dateSource:      dateSource,
+				asOf:            *asOf,
+				mailmap:         mailmap,
+				aliases:         aliases,
+				teams:           teams,
+				unknownTeam:     *unknownTeam,
+				extensions:      extensions,
+				globs:           globs,
+				messageFilter:   messageFilter,
+				useJson:         *useJson,
+				useCsv:          *useCsv,
+				useMarkdown:     useMarkdown,
+				showDebug:       *showDebug,
+				showBreakdown:   *showBreakdown,
+				byDir:           *byDir,
+				byDomain:        *byDomain,
+				bySignature:     *bySignature,
+				verifiedOnly:    *verifiedOnly,
+				top:             *top,
+				minCommits:      *minCommits,
+				minShare:        *minShare,
+				showEmail:       *showEmail,
+				anonymize:       *anonymize,
+				countMerges:     *countMerges,
+				mergeDiffs:      *mergeDiffs,
+				firstParent:     *firstParent,
+				firstCommitOnly: *firstCommitOnly,
+				surviving:       *surviving,
+				noBots:          *noBots,
+				noGenerated:     *noGenerated,
+				maxDiffLines:    *maxDiffLines,
+				coAuthors:       *coAuthors,
+				halfLifeDays:    halfLifeDays,
+				checkpointPath:  *checkpointPath,
+				since:           *filterFlags.since,
+				until:           *filterFlags.until,
+				authors:         filterFlags.authors,
+				nauthors:        filterFlags.nauthors,
+			}
+
+			err = checkCheckpointOpts(opts)
+			if err != nil {
+				return err
+			}
+
+			return hist(revs, paths, opts)
 		},
 	}
 }
@@ -404,6 +868,60 @@ func isOnlyOne(flags ...bool) bool {
 	return true
 }
 
+func parseResolution(s string) (tally.ResolutionMode, error) {
+	switch s {
+	case "auto":
+		return tally.AutoResolution, nil
+	case "day":
+		return tally.DailyResolution, nil
+	case "business-day":
+		return tally.BusinessDayResolution, nil
+	case "week":
+		return tally.WeeklyResolution, nil
+	case "month":
+		return tally.MonthlyResolution, nil
+	case "quarter":
+		return tally.QuarterlyResolution, nil
+	case "year":
+		return tally.YearlyResolution, nil
+	default:
+		return tally.AutoResolution, fmt.Errorf(
+			"unrecognized resolution \"%s\"",
+			s,
+		)
+	}
+}
+
+func parseClock(s string) (tally.ClockMode, error) {
+	switch s {
+	case "hour":
+		return tally.HourOfDay, nil
+	case "weekday":
+		return tally.DayOfWeek, nil
+	default:
+		return tally.HourOfDay, fmt.Errorf("unrecognized clock \"%s\"", s)
+	}
+}
+
+// Parses an interval like "14d" or "2w" into a number of days.
+func parseInterval(s string) (int, error) {
+	unit := 1
+	n := s
+	if strings.HasSuffix(s, "d") {
+		n = strings.TrimSuffix(s, "d")
+	} else if strings.HasSuffix(s, "w") {
+		n = strings.TrimSuffix(s, "w")
+		unit = 7
+	}
+
+	days, err := strconv.Atoi(n)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("unrecognized interval \"%s\"", s)
+	}
+
+	return days * unit, nil
+}
+
 type filterFlags struct {
 	since    *string
 	until    *string
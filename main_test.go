@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/utils/flagutils"
+)
+
+func TestChurnFlagZero(t *testing.T) {
+	cmd := histCmd()
+	err := cmd.flagSet.Parse([]string{"-churn", "0"})
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	churn := cmd.flagSet.Lookup("churn").Value.(*flagutils.OptionalFloat)
+	if !churn.IsSet || churn.Value != 0 {
+		t.Fatalf("expected -churn 0 to be set, got %+v", *churn)
+	}
+}
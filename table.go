@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/pretty"
 	"github.com/sinclairtarget/git-who/internal/tally"
+	"github.com/sinclairtarget/git-who/internal/utils/iterutils"
 )
 
 const narrowWidth = 55
@@ -41,6 +44,7 @@ func table(
 	showEmail bool,
 	countMerges bool,
 	limit int,
+	surviving bool,
 	since string,
 	until string,
 	authors []string,
@@ -68,6 +72,8 @@ func table(
 		countMerges,
 		"limit",
 		limit,
+		"surviving",
+		surviving,
 		"since",
 		since,
 		"until",
@@ -88,7 +94,6 @@ func table(
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
 	}
 
-	populateDiffs := tallyOpts.IsDiffMode()
 	filters := git.LogFilters{
 		Since:    since,
 		Until:    until,
@@ -96,7 +101,64 @@ func table(
 		Nauthors: nauthors,
 	}
 
+	var rankedTallies []tally.FinalTally
+	if surviving {
+		if len(revs) != 1 {
+			return errors.New("-surviving needs a single revision")
+		}
+
+		commits, err := git.SurvivingCommits(ctx, revs[0], paths)
+		if err != nil {
+			return err
+		}
+
+		rankedTallies, err = tally.TallyLastModified(
+			iterutils.WithoutErrors(slices.Values(commits)),
+			tallyOpts,
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		tallies, err := tallyTable(ctx, revs, paths, filters, tallyOpts)
+		if err != nil {
+			return err
+		}
+
+		rankedTallies = tally.Rank(tallies, mode)
+	}
+
+	numFilteredOut := 0
+	if limit > 0 && limit < len(rankedTallies) {
+		numFilteredOut = len(rankedTallies) - limit
+		rankedTallies = rankedTallies[:limit]
+	}
+
+	if useCsv {
+		err := writeCsv(rankedTallies, tallyOpts, showEmail)
+		if err != nil {
+			return err
+		}
+	} else {
+		colwidth := pickWidth(mode, showEmail)
+		writeTable(rankedTallies, colwidth, showEmail, mode, numFilteredOut)
+	}
+
+	return nil
+}
+
+// Tallies the commits found by git log for the "table" subcommand.
+func tallyTable(
+	ctx context.Context,
+	revs []string,
+	paths []string,
+	filters git.LogFilters,
+	tallyOpts tally.TallyOpts,
+) (map[string]tally.Tally, error) {
+	populateDiffs := tallyOpts.IsDiffMode()
+
 	var tallies map[string]tally.Tally
+	var err error
 	if populateDiffs && runtime.GOMAXPROCS(0) > 1 {
 		tallies, err = concurrent.TallyCommits(
 			ctx,
@@ -108,7 +170,7 @@ func table(
 			pretty.AllowDynamic(os.Stdout),
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		// This is fast in the no-diff case even if we don't parallelize it
@@ -120,39 +182,21 @@ func table(
 			populateDiffs,
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		tallies, err = tally.TallyCommits(commits, tallyOpts)
 		if err != nil {
-			return fmt.Errorf("failed to tally commits: %w", err)
+			return nil, fmt.Errorf("failed to tally commits: %w", err)
 		}
 
 		err = closer()
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	rankedTallies := tally.Rank(tallies, mode)
-
-	numFilteredOut := 0
-	if limit > 0 && limit < len(rankedTallies) {
-		numFilteredOut = len(rankedTallies) - limit
-		rankedTallies = rankedTallies[:limit]
-	}
-
-	if useCsv {
-		err := writeCsv(rankedTallies, tallyOpts, showEmail)
-		if err != nil {
-			return err
-		}
-	} else {
-		colwidth := pickWidth(mode, showEmail)
-		writeTable(rankedTallies, colwidth, showEmail, mode, numFilteredOut)
-	}
-
-	return nil
+	return tallies, nil
 }
 
 func toRecord(
@@ -171,8 +215,8 @@ func toRecord(
 	if opts.IsDiffMode() {
 		record = append(
 			record,
-			strconv.Itoa(t.LinesAdded),
-			strconv.Itoa(t.LinesRemoved),
+			strconv.FormatInt(t.LinesAdded, 10),
+			strconv.FormatInt(t.LinesRemoved, 10),
 			strconv.Itoa(t.FileCount),
 		)
 	}
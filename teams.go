@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Reads a file mapping author emails to team names, for tallying by team.
+//
+// Each line gives a team name followed by the emails of its members in angle
+// brackets, like a mailmap:
+//
+//	Platform <alice@corp.com> <bob@corp.com>
+//
+// Lines starting with "#" are comments. Emails are matched case-insensitively.
+func readTeamsFile(path string) (_ map[string]string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading teams file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	teams := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		start := strings.IndexByte(line, '<')
+		team := strings.TrimSpace(line[:max(start, 0)])
+		if start < 0 || team == "" {
+			return nil, fmt.Errorf("malformed teams line: \"%s\"", line)
+		}
+
+		rest := line[start:]
+		for len(rest) > 0 {
+			end := strings.IndexByte(rest, '>')
+			if rest[0] != '<' || end < 0 {
+				return nil, fmt.Errorf("malformed teams line: \"%s\"", line)
+			}
+
+			teams[strings.ToLower(rest[1:end])] = team
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while scanning: %w", err)
+	}
+
+	return teams, nil
+}